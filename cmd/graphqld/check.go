@@ -0,0 +1,133 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/raphaelreyna/graphqld/internal/config"
+	"github.com/raphaelreyna/graphqld/pkg/graphqld"
+	"github.com/rs/zerolog"
+)
+
+// checkResult is what check found for a graph.
+type checkResult struct {
+	DocumentRoot string               `json:"documentRoot"`
+	ServerName   string               `json:"serverName,omitempty"`
+	Diagnostics  graphqld.Diagnostics `json:"diagnostics"`
+}
+
+// check builds every graph in the root directory without serving them, reporting any problems found.
+// It returns the exit status: 0 if every graph built, 1 if any didn't and 2 if they couldn't be checked.
+func check(args []string) int {
+	var (
+		flags  = flag.NewFlagSet("check", flag.ContinueOnError)
+		asJSON = flags.Bool("json", false, "print the results as JSON")
+	)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: graphqld check [-json] [root]")
+		flags.PrintDefaults()
+	}
+
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	if 1 < flags.NArg() {
+		flags.Usage()
+		return 2
+	}
+
+	var (
+		results  []checkResult
+		problems int
+	)
+
+	opts, err := loadConfig(flags.Arg(0))
+	if fe := (*config.FileError)(nil); errors.As(err, &fe) {
+		// an invalid configuration is reported like the problems found in a graph
+		results = append(results, checkResult{
+			DocumentRoot: config.Config.RootDir,
+			Diagnostics:  graphqld.Diagnostics{{File: fe.File, Message: fe.Err.Error()}},
+		})
+		problems++
+	} else if err != nil {
+		fmt.Fprintln(os.Stderr, "unable to load configuration:", err)
+		return 2
+	}
+
+	for _, gopts := range opts.Graphs {
+		var r = checkResult{
+			DocumentRoot: gopts.DocumentRoot,
+			ServerName:   gopts.ServerName,
+			Diagnostics:  checkGraph(gopts),
+		}
+
+		problems += len(r.Diagnostics)
+		results = append(results, r)
+	}
+
+	if *asJSON {
+		var enc = json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.Encode(results)
+	} else {
+		for _, r := range results {
+			if len(r.Diagnostics) == 0 {
+				fmt.Printf("ok\t%s\n", r.DocumentRoot)
+				continue
+			}
+
+			fmt.Printf("FAIL\t%s\n", r.DocumentRoot)
+			for _, d := range r.Diagnostics {
+				fmt.Printf("\t%s\n", d)
+			}
+		}
+	}
+
+	if problems != 0 {
+		return 1
+	}
+
+	return 0
+}
+
+// loadConfig loads the configuration for a command printing its results to stdout, so logs go to stderr instead.
+// The root directory is overriden by root if it's not empty.
+// Graphs are built once by commands, so they aren't hot reloaded.
+func loadConfig(root string) (graphqld.Options, error) {
+	var o = config.Overrides{
+		Root:        root,
+		MinLogLevel: zerolog.WarnLevel,
+		LogOutput:   os.Stderr,
+	}
+
+	if err := config.Load(o); err != nil {
+		return graphqld.Options{}, err
+	}
+
+	var opts = options(config.Config)
+	for idx := range opts.Graphs {
+		opts.Graphs[idx].HotReload = false
+	}
+
+	return opts, nil
+}
+
+// checkGraph builds the graph configured by opts along with its schema, returning the problems found.
+func checkGraph(opts graphqld.GraphOptions) graphqld.Diagnostics {
+	g, err := graphqld.NewGraph(opts)
+	if err != nil {
+		var diagnostics graphqld.Diagnostics
+		if errors.As(err, &diagnostics) {
+			return diagnostics
+		}
+
+		return graphqld.Diagnostics{{File: opts.DocumentRoot, Message: err.Error()}}
+	}
+	g.Close()
+
+	return graphqld.Diagnostics{}
+}
@@ -1,13 +1,19 @@
 package main
 
 import (
+	"errors"
+	"fmt"
+	"os"
+
 	"github.com/raphaelreyna/graphqld/internal/config"
-	"github.com/raphaelreyna/graphqld/internal/server"
+	"github.com/raphaelreyna/graphqld/pkg/graphqld"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 )
 
 func main() {
+	graphqld.RunHelper()
+
 	defer func() {
 		if r := recover(); r != nil {
 			log.Fatal().
@@ -15,13 +21,40 @@ func main() {
 		}
 	}()
 
+	if 1 < len(os.Args) {
+		switch os.Args[1] {
+		case "check":
+			os.Exit(check(os.Args[2:]))
+		case "schema":
+			os.Exit(schema(os.Args[2:]))
+		case "test":
+			os.Exit(test(os.Args[2:]))
+		}
+	}
+
+	if err := config.Load(config.Overrides{}); err != nil {
+		log.Fatal().Err(err).
+			Msg("unable to load configuration")
+	}
+
 	var c = config.Config
 	logConfig(c)
 
-	s, err := server.NewServer(c)
+	s, err := graphqld.NewServer(options(c))
 	if err != nil {
-		log.Error().Err(err).
-			Msg("error creating new server")
+		var diagnostics graphqld.Diagnostics
+		if errors.As(err, &diagnostics) {
+			for _, d := range diagnostics {
+				log.Error().
+					Str("location", d.Location()).
+					Msg(d.Message)
+			}
+
+			err = fmt.Errorf("%d problems found in the graph", len(diagnostics))
+		}
+
+		log.Fatal().Err(err).
+			Msg("unable to build graph schema config")
 	}
 
 	if err := s.Start(); err != nil {
@@ -29,7 +62,7 @@ func main() {
 			Msg("error starting server")
 	}
 
-	if err := s.Stop(); err != nil {
+	if err := s.Close(); err != nil {
 		log.Error().Err(err).
 			Msg("error stopping server")
 	}
@@ -49,7 +82,17 @@ func logConfig(c config.Conf) {
 	logEvent = log.Info().
 		Bool("hot", c.HotReload).
 		Bool("graphiql", c.Graphiql).
-		Str("resolver-wd", c.ResolverDir)
+		Str("resolver-wd", c.ResolverDir).
+		Dur("resolver-timeout", c.ResolverTimeout).
+		Int("max-parallelism", c.MaxParallelism).
+		Int("max-request-parallelism", c.MaxRequestParallelism).
+		Bool("mask-errors", c.MaskErrors).
+		Bool("schema-endpoints", c.SchemaEndpoints).
+		Bool("mock", c.Mock).
+		Str("record", c.Record).
+		Str("replay", c.Replay).
+		Interface("limits", c.Limits).
+		Interface("sandbox", c.Sandbox)
 
 	if c.Context != nil {
 		logEvent = logEvent.Interface("context", c.Context)
@@ -81,7 +124,17 @@ func logConfig(c config.Conf) {
 			Bool("hot", g.HotReload).
 			Bool("graphiql", g.Graphiql).
 			Str("document-root", g.DocumentRoot).
-			Str("resolver-dir", g.ResolverDir)
+			Str("resolver-dir", g.ResolverDir).
+			Dur("resolver-timeout", g.ResolverTimeout).
+			Int("max-parallelism", g.MaxParallelism).
+			Int("max-request-parallelism", g.MaxRequestParallelism).
+			Bool("mask-errors", g.MaskErrors).
+			Bool("schema-endpoints", g.SchemaEndpoints).
+			Bool("mock", g.Mock).
+			Str("record", g.Record).
+			Str("replay", g.Replay).
+			Interface("limits", g.Limits).
+			Interface("sandbox", g.Sandbox)
 
 		if g.ServerName != "" {
 			logEvent = logEvent.Str("server-name", g.ServerName)
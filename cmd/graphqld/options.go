@@ -0,0 +1,147 @@
+package main
+
+import (
+	"github.com/raphaelreyna/graphqld/internal/config"
+	"github.com/raphaelreyna/graphqld/internal/sandbox"
+	"github.com/raphaelreyna/graphqld/pkg/graphqld"
+)
+
+// options returns the options of the server configured by c.
+func options(c config.Conf) graphqld.Options {
+	var opts = graphqld.Options{
+		Addr:   c.Addr,
+		Graphs: make([]graphqld.GraphOptions, 0, len(c.Graphs)),
+	}
+
+	if tls := c.TLS; tls != nil {
+		opts.TLS = &graphqld.TLS{
+			CertFile: tls.CertFile,
+			KeyFile:  tls.KeyFile,
+		}
+	}
+
+	for _, gc := range c.Graphs {
+		opts.Graphs = append(opts.Graphs, graphOptions(gc))
+	}
+
+	return opts
+}
+
+// graphOptions returns the options of the graph configured by gc.
+func graphOptions(gc config.GraphConf) graphqld.GraphOptions {
+	var opts = graphqld.GraphOptions{
+		DocumentRoot:          gc.DocumentRoot,
+		ServerName:            gc.ServerName,
+		ResolverDir:           gc.ResolverDir,
+		HotReload:             gc.HotReload,
+		Graphiql:              gc.Graphiql,
+		SchemaEndpoints:       gc.SchemaEndpoints,
+		Mock:                  gc.Mock,
+		Record:                gc.Record,
+		Replay:                gc.Replay,
+		MaxBodySize:           gc.MaxBodyReadSize,
+		ResolverTimeout:       gc.ResolverTimeout,
+		MaxParallelism:        gc.MaxParallelism,
+		MaxRequestParallelism: gc.MaxRequestParallelism,
+		ErrorCodes:            gc.ErrorCodes,
+		MaskErrors:            gc.MaskErrors,
+		Limits:                limitsOptions(gc.Limits),
+		Sandbox:               sandboxOptions(gc.Sandbox),
+	}
+
+	if 0 < len(gc.Fields) {
+		opts.Fields = make(map[string]graphqld.FieldOptions, len(gc.Fields))
+		for name, fc := range gc.Fields {
+			opts.Fields[name] = fieldOptions(fc)
+		}
+	}
+
+	if ctx := gc.Context; ctx != nil {
+		opts.Context = &graphqld.ContextOptions{
+			ExecPath: ctx.ExecPath,
+			TmpDir:   ctx.TmpDir,
+			Context:  ctx.Context,
+		}
+	}
+
+	if cors := gc.CORS; cors != nil {
+		opts.CORS = &graphqld.CORSOptions{
+			AllowCredentials: cors.AllowCredentials,
+			AllowedHeaders:   cors.AllowedHeaders,
+			AllowedOrigins:   cors.AllowedOrigins,
+			IgnoreOptions:    cors.IgnoreOptions,
+		}
+	}
+
+	if ba := gc.BasicAuth; ba != nil {
+		opts.BasicAuth = &graphqld.BasicAuth{
+			Username: ba.Username,
+			Password: ba.Password,
+		}
+	}
+
+	if u := gc.User; u != nil {
+		opts.User = &graphqld.User{
+			Name:    u.Name,
+			HomeDir: u.HomeDir,
+			Uid:     u.Uid,
+			Gid:     u.Gid,
+		}
+	}
+
+	return opts
+}
+
+func limitsOptions(l config.Limits) graphqld.Limits {
+	return graphqld.Limits{
+		AddressSpace: l.AddressSpace,
+		CPU:          l.CPU,
+		OpenFiles:    l.OpenFiles,
+		Processes:    l.Processes,
+		FileSize:     l.FileSize,
+		Output:       l.Output,
+	}
+}
+
+// sandboxOptions returns the options of the sandbox p; its root is always the document root of the graph.
+func sandboxOptions(p *sandbox.Profile) *graphqld.SandboxProfile {
+	if p == nil {
+		return nil
+	}
+
+	return &graphqld.SandboxProfile{
+		Binds:   p.Binds,
+		Network: p.Network,
+		Seccomp: p.Seccomp,
+	}
+}
+
+func fieldOptions(fc config.FieldConf) graphqld.FieldOptions {
+	var opts = graphqld.FieldOptions{
+		Timeout: fc.Timeout,
+		Limits:  limitsOptions(fc.Limits),
+	}
+
+	if w := fc.Worker; w != nil {
+		opts.Worker = &graphqld.WorkerOptions{
+			PoolSize:    w.PoolSize,
+			MaxRequests: w.MaxRequests,
+		}
+	}
+
+	if f := fc.FastCGI; f != nil {
+		opts.FastCGI = &graphqld.FastCGIOptions{
+			Address: f.Address,
+			Script:  f.Script,
+		}
+	}
+
+	if t := fc.Topic; t != nil {
+		opts.Topic = &graphqld.TopicOptions{
+			Name:   t.Name,
+			Filter: t.Filter,
+		}
+	}
+
+	return opts
+}
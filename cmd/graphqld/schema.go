@@ -0,0 +1,113 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/graphql-go/graphql"
+	"github.com/raphaelreyna/graphqld/pkg/graphqld"
+)
+
+// schema prints the schema of the graphs in the root directory as SDL or as the result of the introspection query.
+// It returns the exit status: 0 if every schema was printed, 1 if a graph couldn't be built and 2 on usage errors.
+func schema(args []string) int {
+	var (
+		flags     = flag.NewFlagSet("schema print", flag.ContinueOnError)
+		asJSON    = flags.Bool("json", false, "print the result of the introspection query instead of SDL")
+		graphName = flags.String("graph", "", "only print the schema of the graph with this server name")
+	)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: graphqld schema print [-json] [-graph name] [root]")
+		flags.PrintDefaults()
+	}
+
+	if len(args) == 0 || args[0] != "print" {
+		flags.Usage()
+		return 2
+	}
+
+	if err := flags.Parse(args[1:]); err != nil {
+		return 2
+	}
+
+	if 1 < flags.NArg() {
+		flags.Usage()
+		return 2
+	}
+
+	opts, err := loadConfig(flags.Arg(0))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "unable to load configuration:", err)
+		return 2
+	}
+
+	var graphs = make([]graphqld.GraphOptions, 0, len(opts.Graphs))
+	for _, gopts := range opts.Graphs {
+		if *graphName == "" || gopts.ServerName == *graphName {
+			graphs = append(graphs, gopts)
+		}
+	}
+
+	if len(graphs) == 0 {
+		fmt.Fprintf(os.Stderr, "no graph named %s\n", *graphName)
+		return 2
+	}
+
+	var introspections = make(map[string]json.RawMessage, len(graphs))
+	for idx, gopts := range graphs {
+		s, err := buildSchema(gopts)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "unable to build %s: %v\n", gopts.DocumentRoot, err)
+			return 1
+		}
+
+		if !*asJSON {
+			// several schemas are told apart by their graph
+			if 1 < len(graphs) {
+				if idx != 0 {
+					fmt.Println()
+				}
+				fmt.Printf("# %s\n\n", gopts.ServerName)
+			}
+
+			fmt.Print(graphqld.PrintSchema(s))
+			continue
+		}
+
+		data, err := graphqld.Introspect(s)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "unable to introspect %s: %v\n", gopts.DocumentRoot, err)
+			return 1
+		}
+		introspections[gopts.ServerName] = data
+	}
+
+	if *asJSON {
+		var out interface{} = introspections
+		if len(graphs) == 1 {
+			// a single graph is printed as is
+			out = introspections[graphs[0].ServerName]
+		}
+
+		var enc = json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.Encode(out)
+	}
+
+	return 0
+}
+
+// buildSchema builds the graph configured by opts, returning its schema.
+func buildSchema(opts graphqld.GraphOptions) (graphql.Schema, error) {
+	g, err := graphqld.NewGraph(opts)
+	if err != nil {
+		return graphql.Schema{}, err
+	}
+	defer g.Close()
+
+	s, _ := g.Schema()
+
+	return s, nil
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"regexp"
+	"time"
+
+	"github.com/raphaelreyna/graphqld/pkg/graphqld"
+	"github.com/raphaelreyna/graphqld/pkg/graphqldtest"
+)
+
+// testReport is what test found for a graph.
+type testReport struct {
+	DocumentRoot string `json:"documentRoot"`
+	ServerName   string `json:"serverName,omitempty"`
+
+	// Error is set if the graph couldn't be built or its cases loaded.
+	Error   string                `json:"error,omitempty"`
+	Results []graphqldtest.Result `json:"results"`
+}
+
+// test runs the test cases of every graph in the root directory against it without serving it.
+// It returns the exit status: 0 if every case passed, 1 if any didn't and 2 if they couldn't be run.
+func test(args []string) int {
+	var (
+		flags  = flag.NewFlagSet("test", flag.ContinueOnError)
+		asJSON = flags.Bool("json", false, "print the results as JSON")
+		run    = flags.String("run", "", "only run the cases whose name matches this regular expression")
+	)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: graphqld test [-json] [-run regexp] [root]")
+		flags.PrintDefaults()
+	}
+
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	if 1 < flags.NArg() {
+		flags.Usage()
+		return 2
+	}
+
+	match, err := regexp.Compile(*run)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "invalid -run:", err)
+		return 2
+	}
+
+	opts, err := loadConfig(flags.Arg(0))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "unable to load configuration:", err)
+		return 2
+	}
+
+	var (
+		reports        = make([]testReport, 0, len(opts.Graphs))
+		passed, failed int
+	)
+
+	for _, gopts := range opts.Graphs {
+		var report = testGraph(gopts, match)
+
+		if report.Error != "" {
+			failed++
+		}
+		for _, r := range report.Results {
+			if r.Passed {
+				passed++
+			} else {
+				failed++
+			}
+		}
+
+		reports = append(reports, report)
+	}
+
+	if *asJSON {
+		var enc = json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.Encode(reports)
+	} else {
+		for _, report := range reports {
+			if report.Error != "" {
+				fmt.Printf("FAIL\t%s\n\t%s\n", report.DocumentRoot, report.Error)
+				continue
+			}
+
+			for _, r := range report.Results {
+				if r.Passed {
+					fmt.Printf("PASS\t%s (%s)\n", r.Name, r.Duration.Round(time.Millisecond))
+					continue
+				}
+
+				fmt.Printf("FAIL\t%s (%s)\n", r.Name, r.Duration.Round(time.Millisecond))
+				for _, f := range r.Failures {
+					fmt.Printf("\t%s\n", f)
+				}
+			}
+		}
+
+		fmt.Printf("%d passed, %d failed\n", passed, failed)
+	}
+
+	if failed != 0 {
+		return 1
+	}
+
+	return 0
+}
+
+// testGraph runs the cases of the graph configured by opts whose name matches match.
+func testGraph(opts graphqld.GraphOptions, match *regexp.Regexp) testReport {
+	var report = testReport{
+		DocumentRoot: opts.DocumentRoot,
+		ServerName:   opts.ServerName,
+		Results:      make([]graphqldtest.Result, 0),
+	}
+
+	cases, err := graphqldtest.LoadCases(opts.DocumentRoot)
+	if err != nil {
+		report.Error = err.Error()
+		return report
+	}
+
+	runner, err := graphqldtest.New(opts)
+	if err != nil {
+		report.Error = err.Error()
+		return report
+	}
+	defer runner.Close()
+
+	for _, c := range cases {
+		if match.MatchString(c.Name) {
+			report.Results = append(report.Results, runner.Run(context.Background(), c))
+		}
+	}
+
+	return report
+}
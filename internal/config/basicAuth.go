@@ -4,9 +4,16 @@ type BasicAuth struct {
 	Username, Password string
 }
 
-func basicAuthFromMap(m map[interface{}]interface{}) *BasicAuth {
-	return &BasicAuth{
-		Username: m["username"].(string),
-		Password: m["password"].(string),
+func basicAuthFromMap(m map[interface{}]interface{}) (*BasicAuth, error) {
+	var (
+		ba       BasicAuth
+		ok1, ok2 bool
+	)
+	ba.Username, ok1 = m["username"].(string)
+	ba.Password, ok2 = m["password"].(string)
+	if !ok1 || !ok2 {
+		return nil, invalidValue("basicAuth", m, "a username and a password")
 	}
+
+	return &ba, nil
 }
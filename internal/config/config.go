@@ -1,14 +1,20 @@
 package config
 
 import (
+	"fmt"
 	"path/filepath"
+	"time"
 
+	"github.com/raphaelreyna/graphqld/internal/sandbox"
 	"github.com/rs/zerolog/log"
 	"github.com/spf13/viper"
 )
 
 var Config Conf
 
+// defaultOutputLimit caps how much of a processes stdout and stderr is read unless configured otherwise.
+const defaultOutputLimit = 10 << 20 // 10MB
+
 type Conf struct {
 	Hostname        string
 	Addr            string
@@ -19,6 +25,36 @@ type Conf struct {
 	User            *User
 	UID, GID        uint32
 	MaxBodyReadSize int64
+	ResolverTimeout time.Duration
+
+	// MaxParallelism caps how many resolvers may run at once for a graph,
+	// MaxRequestParallelism how many may run at once for a single request.
+	MaxParallelism        int
+	MaxRequestParallelism int
+
+	// ErrorCodes maps resolver exit statuses to GraphQL error codes.
+	ErrorCodes map[int]string
+
+	// MaskErrors hides resolver errors from clients unless they're marked as safe.
+	MaskErrors bool
+
+	// SchemaEndpoints serves the schema at /schema.graphql and /schema.json.
+	SchemaEndpoints bool
+
+	// Mock resolves fields with values made up from their types instead of running their resolvers.
+	Mock bool
+
+	// Record is the file every resolver process run for a graph is recorded to, if any.
+	Record string
+
+	// Replay is a recording resolver processes are replayed from instead of being run, if any.
+	Replay string
+
+	// Limits are applied to every process started for a graph.
+	Limits Limits
+
+	// Sandbox is the profile processes started for a graph are sandboxed with, if any.
+	Sandbox *sandbox.Profile
 
 	CORS      *CORSConfig
 	BasicAuth *BasicAuth
@@ -27,43 +63,61 @@ type Conf struct {
 	Log       *Log
 
 	Graphs []GraphConf
+
+	// File is the configuration file that was read, if any.
+	File string
 }
 
-func (c Conf) readInConf() {
+// invalidValue is the error for a value of the wrong type or out of range found at key.
+func invalidValue(key string, v interface{}, expected string) error {
+	return fmt.Errorf("%s: expected %s, got %v", key, expected, v)
+}
+
+func (c Conf) readInConf(o Overrides) error {
 	if err := viper.ReadInConfig(); err != nil {
 		if _, ok := err.(viper.ConfigFileNotFoundError); !ok {
-			log.Fatal().Err(err).
-				Str("configuration-file", viper.ConfigFileUsed()).
-				Msg("error reading configuration file")
+			return &FileError{File: viper.ConfigFileUsed(), Err: err}
 		}
 
 		log.Info().Msg("no configuration file found")
 	}
+	Config.File = viper.ConfigFileUsed()
 
 	Config.Hostname = viper.GetString("hostname")
 	Config.Addr = viper.GetString("address")
 	Config.RootDir = viper.GetString("root")
+	if o.Root != "" {
+		Config.RootDir = o.Root
+	}
 	Config.HotReload = viper.GetBool("hot")
 	Config.Graphiql = viper.GetBool("graphiql")
 	Config.ResolverDir = viper.GetString("resolverDir")
 	Config.MaxBodyReadSize = viper.GetInt64("maxBodySize")
-	Config.CORS = CORSConfigFromViper()
+	Config.ResolverTimeout = viper.GetDuration("resolverTimeout")
+	Config.MaxParallelism = viper.GetInt("maxParallelism")
+	Config.MaxRequestParallelism = viper.GetInt("maxRequestParallelism")
+	Config.MaskErrors = viper.GetBool("maskErrors")
+	Config.SchemaEndpoints = viper.GetBool("schemaEndpoints")
+	Config.Mock = viper.GetBool("mock")
+	Config.Record = viper.GetString("record")
+	Config.Replay = viper.GetString("replay")
+
+	var err error
+	if Config.CORS, err = CORSConfigFromViper(); err != nil {
+		return err
+	}
 
 	if !filepath.IsAbs(Config.RootDir) {
 		path, err := filepath.Abs(Config.RootDir)
 		if err != nil {
-			log.Fatal().Err(err).
-				Str("path", Config.RootDir).
-				Msg("unable to compute absolute root path")
+			return fmt.Errorf("unable to compute absolute root path: %w", err)
 		}
 		Config.RootDir = path
 	}
 	if !filepath.IsAbs(Config.ResolverDir) {
 		path, err := filepath.Abs(Config.ResolverDir)
 		if err != nil {
-			log.Fatal().Err(err).
-				Str("path", Config.ResolverDir).
-				Msg("unable to compute resolver dir root path")
+			return fmt.Errorf("unable to compute absolute resolver dir path: %w", err)
 		}
 		Config.ResolverDir = path
 	}
@@ -72,7 +126,9 @@ func (c Conf) readInConf() {
 		Config.Addr = ":" + viper.GetString("port")
 	}
 
-	Config.User = userFromName(viper.GetString("user"))
+	if Config.User, err = userFromName(viper.GetString("user")); err != nil {
+		return err
+	}
 
 	if x, ok := viper.Get("basicAuth").(map[string]interface{}); ok {
 		m := make(map[interface{}]interface{})
@@ -80,11 +136,60 @@ func (c Conf) readInConf() {
 			m[k] = v
 		}
 
-		Config.BasicAuth = basicAuthFromMap(m)
+		if Config.BasicAuth, err = basicAuthFromMap(m); err != nil {
+			return err
+		}
+	}
+
+	if x, ok := viper.Get("errorCodes").(map[string]interface{}); ok {
+		m := make(map[interface{}]interface{})
+		for k, v := range x {
+			m[k] = v
+		}
+
+		if Config.ErrorCodes, err = errorCodesFromMap(m); err != nil {
+			return err
+		}
+	}
+
+	Config.Limits = Limits{Output: defaultOutputLimit}
+	if viper.IsSet("limits") {
+		// viper lower cases the keys of nested maps so look each one up by name
+		m := make(map[interface{}]interface{})
+		for _, k := range limitsKeys {
+			if x := viper.Get("limits." + k); x != nil {
+				m[k] = x
+			}
+		}
+
+		l, err := limitsFromMap("limits", m)
+		if err != nil {
+			return err
+		}
+		Config.Limits = Config.Limits.Merge(l)
+	}
+
+	switch x := viper.Get("sandbox").(type) {
+	case nil:
+	case map[string]interface{}:
+		m := make(map[interface{}]interface{})
+		for k, v := range x {
+			m[k] = v
+		}
+
+		if Config.Sandbox, err = sandboxFromInterface(m); err != nil {
+			return err
+		}
+	default:
+		if Config.Sandbox, err = sandboxFromInterface(x); err != nil {
+			return err
+		}
 	}
 
 	if x, ok := viper.Get("tls").(map[string]interface{}); ok {
-		Config.TLS = tlsFromMap(x)
+		if Config.TLS, err = tlsFromMap(x); err != nil {
+			return err
+		}
 	}
 
 	if x, ok := viper.Get("context").(map[string]interface{}); ok {
@@ -93,7 +198,9 @@ func (c Conf) readInConf() {
 			m[k] = v
 		}
 
-		Config.Context = contextFromMap(m)
+		if Config.Context, err = contextFromMap(m); err != nil {
+			return err
+		}
 	}
 
 	// Grab the contextExecPath from the environment
@@ -119,4 +226,6 @@ func (c Conf) readInConf() {
 			Config.Context.TmpDir = tmpDir
 		}
 	}
+
+	return nil
 }
@@ -1,9 +1,8 @@
 package config
 
 import (
+	"fmt"
 	"path/filepath"
-
-	"github.com/rs/zerolog/log"
 )
 
 type Context struct {
@@ -12,7 +11,7 @@ type Context struct {
 	Context  interface{}
 }
 
-func contextFromMap(m map[interface{}]interface{}) *Context {
+func contextFromMap(m map[interface{}]interface{}) (*Context, error) {
 	var c = Context{}
 	c.ExecPath, _ = m["execPath"].(string)
 	if c.ExecPath == "" {
@@ -25,9 +24,7 @@ func contextFromMap(m map[interface{}]interface{}) *Context {
 	if !filepath.IsAbs(c.ExecPath) && c.ExecPath != "" {
 		path, err := filepath.Abs(c.ExecPath)
 		if err != nil {
-			log.Fatal().Err(err).
-				Str("path", c.ExecPath).
-				Msg("unable to compute absolute rooth path")
+			return nil, fmt.Errorf("unable to compute absolute path of %s: %w", c.ExecPath, err)
 		}
 		c.ExecPath = path
 	}
@@ -35,9 +32,7 @@ func contextFromMap(m map[interface{}]interface{}) *Context {
 	if !filepath.IsAbs(c.TmpDir) && c.TmpDir != "" {
 		path, err := filepath.Abs(c.TmpDir)
 		if err != nil {
-			log.Fatal().Err(err).
-				Str("path", c.TmpDir).
-				Msg("unable to compute absolute rooth path")
+			return nil, fmt.Errorf("unable to compute absolute path of %s: %w", c.TmpDir, err)
 		}
 		c.TmpDir = path
 	}
@@ -46,7 +41,7 @@ func contextFromMap(m map[interface{}]interface{}) *Context {
 		c.Context = makeJSONable(ctx)
 	}
 
-	return &c
+	return &c, nil
 }
 
 func makeJSONable(v interface{}) interface{} {
@@ -54,7 +49,7 @@ func makeJSONable(v interface{}) interface{} {
 	case map[interface{}]interface{}:
 		var m = make(map[string]interface{}, len(x))
 		for k, v := range x {
-			m[k.(string)] = makeJSONable(v)
+			m[fmt.Sprint(k)] = makeJSONable(v)
 		}
 
 		return m
@@ -1,7 +1,6 @@
 package config
 
 import (
-	"github.com/rs/zerolog/log"
 	"github.com/spf13/viper"
 )
 
@@ -12,40 +11,36 @@ type CORSConfig struct {
 	IgnoreOptions    bool
 }
 
-func CORSConfigFromViper() *CORSConfig {
+func CORSConfigFromViper() (*CORSConfig, error) {
 	var cc CORSConfig
 	if stringMap := viper.GetStringMap("cors"); stringMap != nil {
 		if x, ok := stringMap["allowcredentials"]; ok {
-			x, ok := x.(bool)
+			b, ok := x.(bool)
 			if !ok {
-				log.Fatal().
-					Msg("cors.allowCredentials expected bool")
+				return nil, invalidValue("cors.allowCredentials", x, "a boolean")
 			}
-			cc.AllowCredentials = x
+			cc.AllowCredentials = b
 		}
 
 		if x, ok := stringMap["ignoreoptions"]; ok {
-			x, ok := x.(bool)
+			b, ok := x.(bool)
 			if !ok {
-				log.Fatal().
-					Msg("cors.ignoreOptions expected bool")
+				return nil, invalidValue("cors.ignoreOptions", x, "a boolean")
 			}
-			cc.IgnoreOptions = x
+			cc.IgnoreOptions = b
 		}
 
 		if x, ok := stringMap["allowedheaders"]; ok {
 			ifaces, ok := x.([]interface{})
 			if !ok {
-				log.Fatal().
-					Msg("cors.allowedHeaders expected []string")
+				return nil, invalidValue("cors.allowedHeaders", x, "a list of strings")
 			}
 
 			var headers = make([]string, 0)
 			for _, iface := range ifaces {
 				header, ok := iface.(string)
 				if !ok {
-					log.Fatal().
-						Msg("cors.allowedHeaders expected []string")
+					return nil, invalidValue("cors.allowedHeaders", x, "a list of strings")
 				}
 				headers = append(headers, header)
 			}
@@ -55,26 +50,24 @@ func CORSConfigFromViper() *CORSConfig {
 		if x, ok := stringMap["allowedorigins"]; ok {
 			ifaces, ok := x.([]interface{})
 			if !ok {
-				log.Fatal().
-					Msg("cors.allowedOrigins expected []string")
+				return nil, invalidValue("cors.allowedOrigins", x, "a list of strings")
 			}
 
 			var origins = make([]string, 0)
 			for _, iface := range ifaces {
 				origin, ok := iface.(string)
 				if !ok {
-					log.Fatal().
-						Msg("cors.allowedOrigins expected []string")
+					return nil, invalidValue("cors.allowedOrigins", x, "a list of strings")
 				}
 				origins = append(origins, origin)
 			}
 			cc.AllowedOrigins = origins
 		}
 
-		return &cc
+		return &cc, nil
 	}
 
-	return nil
+	return nil, nil
 }
 
 func CORSConfigFromMap(m map[interface{}]interface{}) *CORSConfig {
@@ -0,0 +1,30 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// errorCodesFromMap reads a table mapping resolver exit statuses to GraphQL error codes.
+func errorCodesFromMap(m map[interface{}]interface{}) (map[int]string, error) {
+	var codes = make(map[int]string)
+
+	for k, v := range m {
+		var status int
+		switch x := k.(type) {
+		case int:
+			status = x
+		case string:
+			var err error
+			if status, err = strconv.Atoi(x); err != nil {
+				return nil, invalidValue("errorCodes", k, "an exit status")
+			}
+		default:
+			return nil, invalidValue("errorCodes", k, "an exit status")
+		}
+
+		codes[status] = fmt.Sprint(v)
+	}
+
+	return codes, nil
+}
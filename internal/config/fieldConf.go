@@ -0,0 +1,161 @@
+package config
+
+import (
+	"fmt"
+	"runtime"
+	"time"
+)
+
+type FieldConf struct {
+	// Timeout overrides the graphs resolver timeout for the field if set;
+	// a zero or negative timeout means the field has none.
+	Timeout *time.Duration
+	Worker  *WorkerConf
+	FastCGI *FastCGIConf
+
+	// Topic binds a subscription field to the events published to a topic.
+	Topic *TopicConf
+
+	// Limits override the graphs limits for the fields resolver.
+	Limits Limits
+}
+
+// WorkerConf configures a pool of long-lived resolver processes.
+type WorkerConf struct {
+	// PoolSize is the max number of worker processes; defaults to the number of CPUs.
+	PoolSize int
+	// MaxRequests is the number of requests a worker serves before being replaced;
+	// 0 means no limit.
+	MaxRequests int
+}
+
+// FastCGIConf binds a field to a script run by a FastCGI application.
+type FastCGIConf struct {
+	// Address is either "unix:/path/to/socket" or "tcp:host:port".
+	Address string
+	// Script is sent as SCRIPT_FILENAME and is relative to the FastCGI application.
+	Script string
+}
+
+// TopicConf binds a subscription field to the events published to a topic.
+type TopicConf struct {
+	Name string
+	// Filter is an executable, relative to the document root, run for each event and subscriber;
+	// it decides whether to deliver the event and shapes it.
+	Filter string
+}
+
+// HTTPConf binds a field to an HTTP endpoint.
+// URL, Body and the values of Headers are text/template templates.
+type HTTPConf struct {
+	Method  string            `yaml:"method"`
+	URL     string            `yaml:"url"`
+	Body    string            `yaml:"body"`
+	Headers map[string]string `yaml:"headers"`
+
+	// ForwardHeaders lists headers to copy from the incoming request to the upstream request.
+	ForwardHeaders []string `yaml:"forwardHeaders"`
+	// ResponseHeaders lists headers to copy from the upstream response to the GraphQL response.
+	ResponseHeaders []string `yaml:"responseHeaders"`
+}
+
+func FastCGIConfFromMap(m map[interface{}]interface{}) *FastCGIConf {
+	var fc FastCGIConf
+	fc.Address, _ = m["address"].(string)
+	fc.Script, _ = m["script"].(string)
+
+	return &fc
+}
+
+func fieldConfsFromMap(m map[interface{}]interface{}) (map[string]FieldConf, error) {
+	var fcs = make(map[string]FieldConf, len(m))
+
+	for k, v := range m {
+		var (
+			name = fmt.Sprint(k)
+			fc   FieldConf
+		)
+
+		fm, ok := v.(map[interface{}]interface{})
+		if !ok {
+			return nil, invalidValue("fields."+name, v, "a map")
+		}
+
+		if x, ok := fm["timeout"]; ok {
+			timeout, err := durationFromInterface("fields."+name+".timeout", x)
+			if err != nil {
+				return nil, err
+			}
+			fc.Timeout = &timeout
+		}
+
+		switch x := fm["worker"].(type) {
+		case bool:
+			if x {
+				fc.Worker = workerConfFromMap(nil)
+			}
+		case map[interface{}]interface{}:
+			fc.Worker = workerConfFromMap(x)
+		}
+
+		if x, ok := fm["fastcgi"].(map[interface{}]interface{}); ok {
+			fc.FastCGI = FastCGIConfFromMap(x)
+		}
+
+		switch x := fm["topic"].(type) {
+		case string:
+			fc.Topic = &TopicConf{Name: x}
+		case map[interface{}]interface{}:
+			var tc TopicConf
+			tc.Name, _ = x["name"].(string)
+			tc.Filter, _ = x["filter"].(string)
+			fc.Topic = &tc
+		}
+
+		if x, ok := fm["limits"].(map[interface{}]interface{}); ok {
+			var err error
+			if fc.Limits, err = limitsFromMap("fields."+name+".limits", x); err != nil {
+				return nil, err
+			}
+		}
+
+		fcs[name] = fc
+	}
+
+	return fcs, nil
+}
+
+func workerConfFromMap(m map[interface{}]interface{}) *WorkerConf {
+	var wc = WorkerConf{
+		PoolSize: runtime.NumCPU(),
+	}
+
+	if x, ok := m["poolSize"].(int); ok && 0 < x {
+		wc.PoolSize = x
+	}
+
+	if x, ok := m["maxRequests"].(int); ok {
+		wc.MaxRequests = x
+	}
+
+	return &wc
+}
+
+// durationFromInterface accepts either a duration string such as "1m30s"
+// or a plain number of seconds.
+func durationFromInterface(key string, v interface{}) (time.Duration, error) {
+	switch x := v.(type) {
+	case string:
+		d, err := time.ParseDuration(x)
+		if err != nil {
+			return 0, fmt.Errorf("%s: invalid duration: %w", key, err)
+		}
+		return d, nil
+	case int:
+		return time.Duration(x) * time.Second, nil
+	case float64:
+		return time.Duration(x * float64(time.Second)), nil
+	default:
+		return 0, invalidValue(key, v, "a duration")
+	}
+}
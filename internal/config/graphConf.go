@@ -1,9 +1,11 @@
 package config
 
 import (
+	"fmt"
 	"path/filepath"
+	"time"
 
-	"github.com/rs/zerolog/log"
+	"github.com/raphaelreyna/graphqld/internal/sandbox"
 )
 
 type GraphConf struct {
@@ -17,52 +19,193 @@ type GraphConf struct {
 	User            *User
 	MaxBodyReadSize int64
 
+	// ResolverTimeout is how long a resolver may run, a graph setting it to 0 runs its resolvers without a timeout.
+	ResolverTimeout    time.Duration
+	resolverTimeoutSet bool
+
+	// MaxParallelism caps how many resolvers may run at once for the graph,
+	// MaxRequestParallelism how many may run at once for a single request.
+	MaxParallelism        int
+	MaxRequestParallelism int
+
+	// ErrorCodes maps resolver exit statuses to GraphQL error codes.
+	ErrorCodes map[int]string
+
+	// MaskErrors hides resolver errors from clients unless they're marked as safe.
+	MaskErrors    bool
+	maskErrorsSet bool
+
+	// SchemaEndpoints serves the schema of the graph at /schema.graphql and /schema.json.
+	SchemaEndpoints    bool
+	schemaEndpointsSet bool
+
+	// Mock resolves the fields of the graph with values made up from their types instead of running their resolvers.
+	Mock    bool
+	mockSet bool
+
+	// Record is the file every resolver process run for the graph is recorded to, if any.
+	Record string
+
+	// Replay is a recording resolver processes are replayed from instead of being run, if any.
+	Replay string
+
+	// Limits are applied to every process started for the graph.
+	Limits Limits
+
+	// Sandbox is the profile processes started for the graph are sandboxed with, if any.
+	Sandbox    *sandbox.Profile
+	sandboxSet bool
+
+	// Fields holds field specific configurations keyed by "Object.field".
+	Fields map[string]FieldConf
+
 	CORS      *CORSConfig
 	BasicAuth *BasicAuth
 	Context   *Context
 }
 
-func graphConfFromMap(m map[interface{}]interface{}) GraphConf {
-	var gc = GraphConf{
+// NewGraphConf returns the configuration of the graph at documentRoot with graphqlds defaults.
+func NewGraphConf(documentRoot string) GraphConf {
+	return GraphConf{
+		DocumentRoot:    documentRoot,
+		ResolverDir:     "/",
 		MaxBodyReadSize: 1 << 20, // 1MB
+		Limits:          Limits{Output: defaultOutputLimit},
 	}
+}
 
-	if x, ok := m["serverName"]; ok {
-		gc.ServerName = x.(string)
+func graphConfFromMap(m map[interface{}]interface{}) (GraphConf, error) {
+	var (
+		gc = GraphConf{
+			MaxBodyReadSize: 1 << 20, // 1MB
+		}
+		ok  bool
+		err error
+	)
+
+	if x, set := m["serverName"]; set {
+		if gc.ServerName, ok = x.(string); !ok {
+			return GraphConf{}, invalidValue("graphs.serverName", x, "a string")
+		}
 	}
 
-	if x, ok := m["hot"]; ok {
-		gc.HotReload = x.(bool)
+	if x, set := m["hot"]; set {
+		if gc.HotReload, ok = x.(bool); !ok {
+			return GraphConf{}, invalidValue("graphs.hot", x, "a boolean")
+		}
 		gc.hotReloadSet = true
 	}
 
-	if x, ok := m["resolverDir"]; ok {
-		gc.ResolverDir = x.(string)
+	if x, set := m["resolverDir"]; set {
+		if gc.ResolverDir, ok = x.(string); !ok {
+			return GraphConf{}, invalidValue("graphs.resolverDir", x, "a string")
+		}
 	}
 
-	if x, ok := m["graphiql"]; ok {
-		gc.Graphiql = x.(bool)
+	if x, set := m["graphiql"]; set {
+		if gc.Graphiql, ok = x.(bool); !ok {
+			return GraphConf{}, invalidValue("graphs.graphiql", x, "a boolean")
+		}
 		gc.graphiqlSet = true
 	}
 
-	if x, ok := m["maxBodySize"]; ok {
-		if y := x.(int64); y != 0 {
+	if x, set := m["maxBodySize"]; set {
+		y, err := sizeFromInterface("graphs.maxBodySize", x)
+		if err != nil {
+			return GraphConf{}, err
+		}
+
+		if y != 0 {
 			gc.MaxBodyReadSize = y
 		}
 	}
 
-	if x, ok := m["user"]; ok {
-		if name := x.(string); name != "" {
-			gc.User = userFromName(name)
+	if x, set := m["resolverTimeout"]; set {
+		if gc.ResolverTimeout, err = durationFromInterface("graphs.resolverTimeout", x); err != nil {
+			return GraphConf{}, err
+		}
+		gc.resolverTimeoutSet = true
+	}
+
+	if x, ok := m["maxParallelism"].(int); ok {
+		gc.MaxParallelism = x
+	}
+
+	if x, ok := m["maxRequestParallelism"].(int); ok {
+		gc.MaxRequestParallelism = x
+	}
+
+	if x, set := m["maskErrors"]; set {
+		if gc.MaskErrors, ok = x.(bool); !ok {
+			return GraphConf{}, invalidValue("graphs.maskErrors", x, "a boolean")
+		}
+		gc.maskErrorsSet = true
+	}
+
+	if x, set := m["schemaEndpoints"]; set {
+		if gc.SchemaEndpoints, ok = x.(bool); !ok {
+			return GraphConf{}, invalidValue("graphs.schemaEndpoints", x, "a boolean")
+		}
+		gc.schemaEndpointsSet = true
+	}
+
+	if x, set := m["mock"]; set {
+		if gc.Mock, ok = x.(bool); !ok {
+			return GraphConf{}, invalidValue("graphs.mock", x, "a boolean")
+		}
+		gc.mockSet = true
+	}
+
+	if x, ok := m["record"].(string); ok {
+		gc.Record = x
+	}
+
+	if x, ok := m["replay"].(string); ok {
+		gc.Replay = x
+	}
+
+	if x, ok := m["limits"].(map[interface{}]interface{}); ok {
+		if gc.Limits, err = limitsFromMap("graphs.limits", x); err != nil {
+			return GraphConf{}, err
+		}
+	}
+
+	if x, set := m["sandbox"]; set {
+		if gc.Sandbox, err = sandboxFromInterface(x); err != nil {
+			return GraphConf{}, err
+		}
+		gc.sandboxSet = true
+	}
+
+	if x, ok := m["errorCodes"].(map[interface{}]interface{}); ok {
+		if gc.ErrorCodes, err = errorCodesFromMap(x); err != nil {
+			return GraphConf{}, err
+		}
+	}
+
+	if x, ok := m["fields"].(map[interface{}]interface{}); ok {
+		if gc.Fields, err = fieldConfsFromMap(x); err != nil {
+			return GraphConf{}, err
+		}
+	}
+
+	if x, set := m["user"]; set {
+		name, ok := x.(string)
+		if !ok {
+			return GraphConf{}, invalidValue("graphs.user", x, "a user name")
+		}
+
+		if name != "" {
+			if gc.User, err = userFromName(name); err != nil {
+				return GraphConf{}, err
+			}
 		}
 	}
 
 	if !filepath.IsAbs(gc.ResolverDir) && gc.ResolverDir != "" {
 		path, err := filepath.Abs(gc.ResolverDir)
 		if err != nil {
-			log.Fatal().Err(err).
-				Str("path", gc.ResolverDir).
-				Msg("unable to compute resolver dir root path")
+			return GraphConf{}, fmt.Errorf("unable to compute absolute resolver dir path: %w", err)
 		}
 		gc.ResolverDir = path
 	}
@@ -72,12 +215,16 @@ func graphConfFromMap(m map[interface{}]interface{}) GraphConf {
 	}
 
 	if x, ok := m["basicAuth"].(map[interface{}]interface{}); ok {
-		gc.BasicAuth = basicAuthFromMap(x)
+		if gc.BasicAuth, err = basicAuthFromMap(x); err != nil {
+			return GraphConf{}, err
+		}
 	}
 
 	if x, ok := m["context"].(map[interface{}]interface{}); ok {
-		gc.Context = contextFromMap(x)
+		if gc.Context, err = contextFromMap(x); err != nil {
+			return GraphConf{}, err
+		}
 	}
 
-	return gc
+	return gc, nil
 }
@@ -1,6 +1,8 @@
 package config
 
 import (
+	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"strings"
@@ -10,7 +12,9 @@ import (
 	"github.com/spf13/viper"
 )
 
-func init() {
+// setup points viper at the configuration file and environment; it's left to Load so that importing the package
+// has no side effects on programs that don't read the configuration.
+func setup() {
 	viper.SetConfigName("graphqld")
 	viper.SetConfigType("yaml")
 	viper.AddConfigPath(".")
@@ -24,17 +28,71 @@ func init() {
 		"LOGJSON", "LOG_JSON",
 		"LOGCOLOR", "LOG_COLOR",
 		"MAXBODYSIZE", "MAX_BODY_SIZE",
+		"RESOLVERTIMEOUT", "RESOLVER_TIMEOUT",
+		"MAXREQUESTPARALLELISM", "MAX_REQUEST_PARALLELISM",
+		"MAXPARALLELISM", "MAX_PARALLELISM",
+		"MASKERRORS", "MASK_ERRORS",
+		"SCHEMAENDPOINTS", "SCHEMA_ENDPOINTS",
 	))
 
 	viper.SetEnvPrefix("GRAPHQLD")
 	viper.AutomaticEnv()
 
 	defaults()
+}
+
+// Overrides are settings a command sets itself, taking precedence over the configuration file and environment.
+type Overrides struct {
+	// Root replaces the configured root directory if it's not empty.
+	Root string
+
+	// MinLogLevel drops logs below it whatever the configured log level is.
+	MinLogLevel zerolog.Level
+
+	// LogOutput is where logs are written to, stdout if it's nil.
+	LogOutput io.Writer
+}
+
+// FileError is an invalid configuration, along with the configuration file it was read from if there was one.
+type FileError struct {
+	File string
+	Err  error
+}
+
+func (e *FileError) Error() string {
+	if e.File == "" {
+		return e.Err.Error()
+	}
+
+	return e.File + ": " + e.Err.Error()
+}
+
+func (e *FileError) Unwrap() error {
+	return e.Err
+}
+
+// Load reads the configuration file and environment into Config, along with the graphs found in its root directory.
+// An invalid configuration is returned as a *FileError.
+func Load(o Overrides) error {
+	setup()
 
-	Config.readInConf()
+	var out io.Writer = os.Stdout
+	if o.LogOutput != nil {
+		out = o.LogOutput
+		log.Logger = log.Output(out)
+	}
+	log.Logger = log.Logger.Level(o.MinLogLevel)
 
+	if err := Config.readInConf(o); err != nil {
+		return asFileError(err)
+	}
+
+	Config.Log = &Log{Level: zerolog.InfoLevel}
 	if x, ok := viper.Get("log").(map[string]interface{}); ok {
-		Config.Log = logFromMap(x)
+		var err error
+		if Config.Log, err = logFromMap(x); err != nil {
+			return asFileError(err)
+		}
 	}
 
 	{
@@ -42,17 +100,21 @@ func init() {
 
 		if !logc.JSON {
 			log.Logger = log.Output(zerolog.ConsoleWriter{
-				Out:     os.Stdout,
+				Out:     out,
 				NoColor: !logc.Color,
 			})
 		}
 
-		log.Logger = log.Logger.Level(logc.Level)
+		var level = logc.Level
+		if level < o.MinLogLevel {
+			level = o.MinLogLevel
+		}
+		log.Logger = log.Logger.Level(level)
 	}
 
 	if !viper.GetBool("logJSON") {
 		log.Logger = log.Output(zerolog.ConsoleWriter{
-			Out:     os.Stdout,
+			Out:     out,
 			NoColor: !viper.GetBool("logColor"),
 		})
 	}
@@ -61,17 +123,48 @@ func init() {
 		Str("file", viper.ConfigFileUsed()).
 		Msg("read configuration")
 
+	graphs, err := discoverGraphs()
+	if err != nil {
+		return err
+	}
+	Config.Graphs = graphs
+
+	return nil
+}
+
+// asFileError attributes err to the configuration file that was read.
+func asFileError(err error) error {
+	if _, ok := err.(*FileError); ok {
+		return err
+	}
+
+	return &FileError{File: viper.ConfigFileUsed(), Err: err}
+}
+
+// discoverGraphs finds the graphs in the root directory, applying the defaults and graph specific configuration to each;
+// the root directory holds a single graph if none of its directories do.
+func discoverGraphs() ([]GraphConf, error) {
 	var (
 		confGraphs = make(map[string]GraphConf)
 		dirGraphs  = make([]GraphConf, 0)
 	)
 	{
 		if iface := viper.Get("graphs"); iface != nil {
-			for _, v := range iface.([]interface{}) {
-				var (
-					m  = v.(map[interface{}]interface{})
-					gc = graphConfFromMap(m)
-				)
+			vs, ok := iface.([]interface{})
+			if !ok {
+				return nil, asFileError(invalidValue("graphs", iface, "a list of graphs"))
+			}
+
+			for _, v := range vs {
+				m, ok := v.(map[interface{}]interface{})
+				if !ok {
+					return nil, asFileError(invalidValue("graphs", v, "a graph"))
+				}
+
+				gc, err := graphConfFromMap(m)
+				if err != nil {
+					return nil, asFileError(err)
+				}
 
 				confGraphs[gc.ServerName] = gc
 			}
@@ -79,8 +172,7 @@ func init() {
 
 		dirs, err := os.ReadDir(Config.RootDir)
 		if err != nil {
-			log.Fatal().Err(err).
-				Msg("unable to read root directory")
+			return nil, fmt.Errorf("unable to read root directory: %w", err)
 		}
 
 		for _, item := range dirs {
@@ -95,8 +187,7 @@ func init() {
 
 			isOk, err := isGraphDir(path)
 			if err != nil {
-				log.Fatal().Err(err).
-					Msg("unable to check if directory has graph")
+				return nil, fmt.Errorf("unable to check if %s has a graph: %w", path, err)
 			}
 
 			if !isOk {
@@ -110,6 +201,18 @@ func init() {
 				ResolverDir:     Config.ResolverDir,
 				User:            Config.User,
 				MaxBodyReadSize: Config.MaxBodyReadSize,
+				ResolverTimeout: Config.ResolverTimeout,
+
+				MaxParallelism:        Config.MaxParallelism,
+				MaxRequestParallelism: Config.MaxRequestParallelism,
+				ErrorCodes:            Config.ErrorCodes,
+				MaskErrors:            Config.MaskErrors,
+				SchemaEndpoints:       Config.SchemaEndpoints,
+				Mock:                  Config.Mock,
+				Record:                Config.Record,
+				Replay:                Config.Replay,
+				Limits:                Config.Limits,
+				Sandbox:               Config.Sandbox,
 			}
 
 			if cc := Config.CORS; cc != nil {
@@ -125,8 +228,7 @@ func init() {
 			}
 
 			if err := checkDomain(name); err != nil {
-				log.Fatal().Err(err).
-					Msg("invalid domain name")
+				return nil, fmt.Errorf("invalid domain name %s: %w", name, err)
 			}
 			gc.ServerName = name
 
@@ -141,6 +243,18 @@ func init() {
 				ResolverDir:     Config.ResolverDir,
 				User:            Config.User,
 				MaxBodyReadSize: Config.MaxBodyReadSize,
+				ResolverTimeout: Config.ResolverTimeout,
+
+				MaxParallelism:        Config.MaxParallelism,
+				MaxRequestParallelism: Config.MaxRequestParallelism,
+				ErrorCodes:            Config.ErrorCodes,
+				MaskErrors:            Config.MaskErrors,
+				SchemaEndpoints:       Config.SchemaEndpoints,
+				Mock:                  Config.Mock,
+				Record:                Config.Record,
+				Replay:                Config.Replay,
+				Limits:                Config.Limits,
+				Sandbox:               Config.Sandbox,
 			}
 
 			if cc := Config.CORS; cc != nil {
@@ -153,8 +267,7 @@ func init() {
 
 			if Config.Hostname != "" {
 				if err := checkDomain(Config.Hostname); err != nil {
-					log.Fatal().Err(err).
-						Msg("invalid domain name")
+					return nil, fmt.Errorf("invalid domain name %s: %w", Config.Hostname, err)
 				}
 			}
 
@@ -165,11 +278,11 @@ func init() {
 	// compare the graph configs obtained from that graphs viper vs
 	// the configs generated by scanning the filesystem + default viper config
 	// (individual graph config overrides)
-	Config.Graphs = make([]GraphConf, 0)
+	var graphs = make([]GraphConf, 0, len(dirGraphs))
 	for _, graph := range dirGraphs {
 		confGraph, ok := confGraphs[graph.ServerName]
 		if !ok {
-			Config.Graphs = append(Config.Graphs, graph)
+			graphs = append(graphs, graph)
 			continue
 		}
 
@@ -189,6 +302,52 @@ func init() {
 			graph.MaxBodyReadSize = x
 		}
 
+		if x := confGraph.ResolverTimeout; confGraph.resolverTimeoutSet {
+			graph.ResolverTimeout = x
+		}
+
+		if x := confGraph.MaxParallelism; x > 0 {
+			graph.MaxParallelism = x
+		}
+
+		if x := confGraph.MaxRequestParallelism; x > 0 {
+			graph.MaxRequestParallelism = x
+		}
+
+		if x := confGraph.MaskErrors; confGraph.maskErrorsSet {
+			graph.MaskErrors = x
+		}
+
+		if x := confGraph.SchemaEndpoints; confGraph.schemaEndpointsSet {
+			graph.SchemaEndpoints = x
+		}
+
+		if x := confGraph.Mock; confGraph.mockSet {
+			graph.Mock = x
+		}
+
+		if x := confGraph.Record; x != "" {
+			graph.Record = x
+		}
+
+		if x := confGraph.Replay; x != "" {
+			graph.Replay = x
+		}
+
+		graph.Limits = graph.Limits.Merge(confGraph.Limits)
+
+		if x := confGraph.Sandbox; confGraph.sandboxSet {
+			graph.Sandbox = x
+		}
+
+		if x := confGraph.ErrorCodes; x != nil {
+			graph.ErrorCodes = x
+		}
+
+		if x := confGraph.Fields; x != nil {
+			graph.Fields = x
+		}
+
 		if x := confGraph.CORS; x != nil {
 			graph.CORS = x
 		}
@@ -205,8 +364,22 @@ func init() {
 			graph.User = x
 		}
 
-		Config.Graphs = append(Config.Graphs, graph)
+		graphs = append(graphs, graph)
 	}
+
+	// each graph gets its own copy of its sandbox profile, binding its own document root
+	for idx := range graphs {
+		var graph = &graphs[idx]
+		if graph.Sandbox == nil {
+			continue
+		}
+
+		var p = *graph.Sandbox
+		p.Root = graph.DocumentRoot
+		graph.Sandbox = &p
+	}
+
+	return graphs, nil
 }
 
 func isGraphDir(path string) (bool, error) {
@@ -218,7 +391,7 @@ func isGraphDir(path string) (bool, error) {
 	for _, entry := range entries {
 		var name = entry.Name()
 
-		if name == "Query" || name == "Mutation" {
+		if name == "Query" || name == "Mutation" || name == "Subscription" {
 			return true, nil
 		}
 	}
@@ -240,4 +413,12 @@ func defaults() {
 	viper.SetDefault("logColor", true)
 	viper.SetDefault("resolverDir", "/")
 	viper.SetDefault("maxBodySize", 1<<20) // 1 MB
+	viper.SetDefault("resolverTimeout", 0)
+	viper.SetDefault("maxParallelism", 0)
+	viper.SetDefault("maxRequestParallelism", 0)
+	viper.SetDefault("maskErrors", false)
+	viper.SetDefault("schemaEndpoints", false)
+	viper.SetDefault("mock", false)
+	viper.SetDefault("record", "")
+	viper.SetDefault("replay", "")
 }
@@ -0,0 +1,146 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Limits are resource limits applied to the processes graphqld starts; zero means no limit.
+type Limits struct {
+	// AddressSpace is the maximum size of a process's virtual memory in bytes.
+	AddressSpace int64
+	// CPU is the maximum amount of CPU time a process may use, rounded up to whole seconds.
+	CPU time.Duration
+	// OpenFiles is the maximum number of file descriptors a process may open.
+	OpenFiles int64
+	// Processes is the maximum number of processes the user running a process may have.
+	Processes int64
+	// FileSize is the maximum size in bytes of any file a process writes.
+	FileSize int64
+	// Output is the maximum number of bytes read from each of a process's stdout and stderr.
+	Output int64
+}
+
+// Merge returns l with every limit set in o replacing its own.
+func (l Limits) Merge(o Limits) Limits {
+	if o.AddressSpace != 0 {
+		l.AddressSpace = o.AddressSpace
+	}
+
+	if o.CPU != 0 {
+		l.CPU = o.CPU
+	}
+
+	if o.OpenFiles != 0 {
+		l.OpenFiles = o.OpenFiles
+	}
+
+	if o.Processes != 0 {
+		l.Processes = o.Processes
+	}
+
+	if o.FileSize != 0 {
+		l.FileSize = o.FileSize
+	}
+
+	if o.Output != 0 {
+		l.Output = o.Output
+	}
+
+	return l
+}
+
+// limitsKeys are the keys of a limits configuration.
+var limitsKeys = []string{"addressSpace", "cpu", "openFiles", "processes", "fileSize", "output"}
+
+// limitsFromMap reads the limits configured under key.
+func limitsFromMap(key string, m map[interface{}]interface{}) (Limits, error) {
+	var (
+		l   Limits
+		err error
+	)
+
+	if x, ok := m["addressSpace"]; ok {
+		if l.AddressSpace, err = sizeFromInterface(key+".addressSpace", x); err != nil {
+			return Limits{}, err
+		}
+	}
+
+	if x, ok := m["cpu"]; ok {
+		if l.CPU, err = durationFromInterface(key+".cpu", x); err != nil {
+			return Limits{}, err
+		}
+	}
+
+	if x, ok := m["openFiles"]; ok {
+		n, ok := x.(int)
+		if !ok {
+			return Limits{}, invalidValue(key+".openFiles", x, "a number")
+		}
+		l.OpenFiles = int64(n)
+	}
+
+	if x, ok := m["processes"]; ok {
+		n, ok := x.(int)
+		if !ok {
+			return Limits{}, invalidValue(key+".processes", x, "a number")
+		}
+		l.Processes = int64(n)
+	}
+
+	if x, ok := m["fileSize"]; ok {
+		if l.FileSize, err = sizeFromInterface(key+".fileSize", x); err != nil {
+			return Limits{}, err
+		}
+	}
+
+	if x, ok := m["output"]; ok {
+		if l.Output, err = sizeFromInterface(key+".output", x); err != nil {
+			return Limits{}, err
+		}
+	}
+
+	return l, nil
+}
+
+// sizeFromInterface accepts either a plain number of bytes
+// or a string such as "512KB", "64MB" or "1GB".
+func sizeFromInterface(key string, v interface{}) (int64, error) {
+	switch x := v.(type) {
+	case int:
+		return int64(x), nil
+	case int64:
+		return x, nil
+	case string:
+		var (
+			s          = strings.ToUpper(strings.TrimSpace(x))
+			mult int64 = 1
+		)
+
+		for _, unit := range []struct {
+			suffix string
+			mult   int64
+		}{
+			{"GB", 1 << 30}, {"MB", 1 << 20}, {"KB", 1 << 10},
+			{"G", 1 << 30}, {"M", 1 << 20}, {"K", 1 << 10},
+			{"B", 1},
+		} {
+			if strings.HasSuffix(s, unit.suffix) {
+				s = strings.TrimSpace(strings.TrimSuffix(s, unit.suffix))
+				mult = unit.mult
+				break
+			}
+		}
+
+		n, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("%s: invalid size %q", key, x)
+		}
+
+		return n * mult, nil
+	default:
+		return 0, invalidValue(key, v, "a size")
+	}
+}
@@ -4,7 +4,6 @@ import (
 	"strings"
 
 	"github.com/rs/zerolog"
-	"github.com/rs/zerolog/log"
 )
 
 type Log struct {
@@ -14,10 +13,15 @@ type Log struct {
 	Level zerolog.Level
 }
 
-func logFromMap(m map[string]interface{}) *Log {
+func logFromMap(m map[string]interface{}) (*Log, error) {
 	var logConf Log
 
-	switch l := m["level"].(string); strings.ToLower(l) {
+	var l, ok = m["level"].(string)
+	if x, set := m["level"]; set && !ok {
+		return nil, invalidValue("log.level", x, "info | warn | error | fatal | disabled")
+	}
+
+	switch strings.ToLower(l) {
 	case "info":
 		logConf.Level = zerolog.InfoLevel
 	case "warn":
@@ -30,10 +34,7 @@ func logFromMap(m map[string]interface{}) *Log {
 		logConf.Level = zerolog.Disabled
 	default:
 		if l != "" {
-			log.Fatal().
-				Str("value", l).
-				Str("expected", "info | warn | error | fatal | disabled").
-				Msg("invalid log level")
+			return nil, invalidValue("log.level", l, "info | warn | error | fatal | disabled")
 		}
 
 		logConf.Level = zerolog.InfoLevel
@@ -51,5 +52,5 @@ func logFromMap(m map[string]interface{}) *Log {
 		logConf.Color = x
 	}
 
-	return &logConf
+	return &logConf, nil
 }
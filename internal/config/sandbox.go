@@ -0,0 +1,67 @@
+package config
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/raphaelreyna/graphqld/internal/sandbox"
+)
+
+// sandboxFromInterface reads a sandbox profile; true enables the default profile and false disables sandboxing.
+func sandboxFromInterface(v interface{}) (*sandbox.Profile, error) {
+	var p sandbox.Profile
+
+	switch x := v.(type) {
+	case bool:
+		if !x {
+			return nil, nil
+		}
+	case map[interface{}]interface{}:
+		if y, ok := x["network"]; ok {
+			network, ok := y.(bool)
+			if !ok {
+				return nil, invalidValue("sandbox.network", y, "true or false")
+			}
+			p.Network = network
+		}
+
+		if y, ok := x["binds"]; ok {
+			paths, ok := y.([]interface{})
+			if !ok {
+				return nil, invalidValue("sandbox.binds", y, "a list of paths")
+			}
+
+			for _, iface := range paths {
+				path, ok := iface.(string)
+				if !ok || path == "" {
+					return nil, invalidValue("sandbox.binds", y, "a list of paths")
+				}
+
+				path, err := filepath.Abs(path)
+				if err != nil {
+					return nil, fmt.Errorf("sandbox.binds: %w", err)
+				}
+				p.Binds = append(p.Binds, path)
+			}
+		}
+
+		if y, ok := x["seccomp"]; ok {
+			calls, ok := y.([]interface{})
+			if !ok {
+				return nil, invalidValue("sandbox.seccomp", y, "a list of system calls")
+			}
+
+			for _, call := range calls {
+				p.Seccomp = append(p.Seccomp, fmt.Sprint(call))
+			}
+		}
+	default:
+		return nil, invalidValue("sandbox", v, "true, false or a sandbox profile")
+	}
+
+	if err := p.Validate(); err != nil {
+		return nil, fmt.Errorf("sandbox: %w", err)
+	}
+
+	return &p, nil
+}
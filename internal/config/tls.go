@@ -4,9 +4,16 @@ type TLS struct {
 	CertFile, KeyFile string
 }
 
-func tlsFromMap(m map[string]interface{}) *TLS {
-	return &TLS{
-		CertFile: m["cert"].(string),
-		KeyFile:  m["key"].(string),
+func tlsFromMap(m map[string]interface{}) (*TLS, error) {
+	var (
+		t        TLS
+		ok1, ok2 bool
+	)
+	t.CertFile, ok1 = m["cert"].(string)
+	t.KeyFile, ok2 = m["key"].(string)
+	if !ok1 || !ok2 {
+		return nil, invalidValue("tls", m, "a cert and a key")
 	}
+
+	return &t, nil
 }
@@ -1,10 +1,9 @@
 package config
 
 import (
+	"fmt"
 	"os/user"
 	"strconv"
-
-	"github.com/rs/zerolog/log"
 )
 
 type User struct {
@@ -14,41 +13,36 @@ type User struct {
 	Gid     uint32
 }
 
-func userFromName(name string) *User {
+func userFromName(name string) (*User, error) {
 	var (
 		u User
 	)
 
 	uu, err := user.Current()
 	if err != nil {
-		log.Fatal().Err(err).
-			Msg("unable to get current user from the os")
+		return nil, fmt.Errorf("unable to get current user from the os: %w", err)
 	}
 
 	// non root users cant set uid
 	if uu.Uid != "0" {
-		return nil
+		return nil, nil
 	}
 
 	if name != "" {
 		uu, err = user.Lookup(name)
 		if err != nil {
-			log.Fatal().Err(err).
-				Str("user", name).
-				Msg("unable to get user from the os")
+			return nil, fmt.Errorf("user: %w", err)
 		}
 	} else {
-		return nil
+		return nil, nil
 	}
 
 	var uid, gid uint64
 	if uid, err = strconv.ParseUint(uu.Uid, 10, 32); err != nil {
-		log.Fatal().Err(err).
-			Msg("this os does not support uint32 user ids")
+		return nil, fmt.Errorf("this os does not support uint32 user ids: %w", err)
 	}
 	if gid, err = strconv.ParseUint(uu.Gid, 10, 32); err != nil {
-		log.Fatal().Err(err).
-			Msg("this os does not support uint32 group ids")
+		return nil, fmt.Errorf("this os does not support uint32 group ids: %w", err)
 	}
 
 	u.Uid = uint32(uid)
@@ -56,5 +50,5 @@ func userFromName(name string) *User {
 	u.Name = name
 	u.HomeDir = uu.HomeDir
 
-	return &u
+	return &u, nil
 }
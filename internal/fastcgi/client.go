@@ -0,0 +1,248 @@
+// Package fastcgi implements just enough of the FastCGI protocol to act as a
+// web server sending responder requests to a FastCGI application (such as php-fpm).
+package fastcgi
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+
+	"github.com/raphaelreyna/graphqld/internal/limits"
+)
+
+const (
+	version1 = 1
+
+	typeBeginRequest = 1
+	typeEndRequest   = 3
+	typeParams       = 4
+	typeStdin        = 5
+	typeStdout       = 6
+	typeStderr       = 7
+
+	roleResponder = 1
+
+	maxContentLength = 65535
+
+	// every request is sent over its own connection so they all get the same id
+	requestID = 1
+)
+
+var ErrProtocol = errors.New("fastcgi protocol error")
+
+// Response is what a FastCGI application sent back for a request.
+type Response struct {
+	Stdout    []byte
+	Stderr    []byte
+	AppStatus uint32
+}
+
+// Client sends requests to a FastCGI application.
+type Client struct {
+	Network, Address string
+	// MaxOutput caps how many bytes the application may send to either stdout or stderr, 0 meaning no cap.
+	MaxOutput int64
+}
+
+// NewClient returns a Client for the application listening on addr.
+// addr is either "unix:/path/to/socket", "tcp:host:port", an absolute path to a unix
+// socket or a "host:port" TCP address.
+func NewClient(addr string) *Client {
+	switch {
+	case strings.HasPrefix(addr, "unix:"):
+		return &Client{Network: "unix", Address: strings.TrimPrefix(addr, "unix:")}
+	case strings.HasPrefix(addr, "tcp:"):
+		return &Client{Network: "tcp", Address: strings.TrimPrefix(addr, "tcp:")}
+	case strings.HasPrefix(addr, "/"):
+		return &Client{Network: "unix", Address: addr}
+	default:
+		return &Client{Network: "tcp", Address: addr}
+	}
+}
+
+func (c *Client) String() string {
+	return c.Network + ":" + c.Address
+}
+
+// Do sends a single responder request made up of params and stdin,
+// and waits for the application to end it.
+func (c *Client) Do(ctx context.Context, params map[string]string, stdin []byte) (*Response, error) {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, c.Network, c.Address)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	// unblock any reads or writes if ctx is cancelled
+	var done = make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.Close()
+		case <-done:
+		}
+	}()
+
+	var w = bufio.NewWriter(conn)
+
+	// begin request
+	{
+		var body = [8]byte{0, roleResponder}
+		if err := writeRecord(w, typeBeginRequest, body[:]); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := writeStream(w, typeParams, encodeParams(params)); err != nil {
+		return nil, err
+	}
+
+	if err := writeStream(w, typeStdin, stdin); err != nil {
+		return nil, err
+	}
+
+	if err := w.Flush(); err != nil {
+		return nil, err
+	}
+
+	resp, err := readResponse(bufio.NewReader(conn), c.MaxOutput)
+	if err != nil && ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	return resp, err
+}
+
+// readResponse reads the records of a response until the end request record,
+// giving up on the response as soon as its stdout or stderr grows past max bytes.
+func readResponse(r io.Reader, max int64) (*Response, error) {
+	var (
+		stdout = limits.Buffer{Max: max}
+		stderr = limits.Buffer{Max: max}
+		header [8]byte
+	)
+
+	for {
+		if _, err := io.ReadFull(r, header[:]); err != nil {
+			return nil, err
+		}
+
+		if header[0] != version1 {
+			return nil, fmt.Errorf("%w: unsupported version %d", ErrProtocol, header[0])
+		}
+
+		var (
+			recType       = header[1]
+			contentLength = binary.BigEndian.Uint16(header[4:6])
+			paddingLength = header[6]
+			content       = make([]byte, int(contentLength)+int(paddingLength))
+		)
+
+		if _, err := io.ReadFull(r, content); err != nil {
+			return nil, err
+		}
+		content = content[:contentLength]
+
+		switch recType {
+		case typeStdout:
+			if _, err := stdout.Write(content); err != nil {
+				return nil, fmt.Errorf("fastcgi application wrote more than %d bytes to stdout: %w", max, err)
+			}
+		case typeStderr:
+			if _, err := stderr.Write(content); err != nil {
+				return nil, fmt.Errorf("fastcgi application wrote more than %d bytes to stderr: %w", max, err)
+			}
+		case typeEndRequest:
+			if len(content) < 5 {
+				return nil, fmt.Errorf("%w: short end request record", ErrProtocol)
+			}
+
+			if protocolStatus := content[4]; protocolStatus != 0 {
+				return nil, fmt.Errorf("%w: request rejected with protocol status %d", ErrProtocol, protocolStatus)
+			}
+
+			return &Response{
+				Stdout:    stdout.Bytes(),
+				Stderr:    stderr.Bytes(),
+				AppStatus: binary.BigEndian.Uint32(content[:4]),
+			}, nil
+		}
+	}
+}
+
+func writeRecord(w io.Writer, recType byte, content []byte) error {
+	var (
+		header  [8]byte
+		padding = -len(content) & 7
+	)
+
+	header[0] = version1
+	header[1] = recType
+	binary.BigEndian.PutUint16(header[2:4], requestID)
+	binary.BigEndian.PutUint16(header[4:6], uint16(len(content)))
+	header[6] = byte(padding)
+
+	if _, err := w.Write(header[:]); err != nil {
+		return err
+	}
+
+	if _, err := w.Write(content); err != nil {
+		return err
+	}
+
+	_, err := w.Write(make([]byte, padding))
+	return err
+}
+
+// writeStream writes data as a sequence of records terminated by an empty one.
+func writeStream(w io.Writer, recType byte, data []byte) error {
+	for 0 < len(data) {
+		var n = len(data)
+		if maxContentLength < n {
+			n = maxContentLength
+		}
+
+		if err := writeRecord(w, recType, data[:n]); err != nil {
+			return err
+		}
+		data = data[n:]
+	}
+
+	return writeRecord(w, recType, nil)
+}
+
+func encodeParams(params map[string]string) []byte {
+	var buf bytes.Buffer
+
+	for k, v := range params {
+		writeLength(&buf, len(k))
+		writeLength(&buf, len(v))
+		buf.WriteString(k)
+		buf.WriteString(v)
+	}
+
+	return buf.Bytes()
+}
+
+func writeLength(buf *bytes.Buffer, n int) {
+	if n < 128 {
+		buf.WriteByte(byte(n))
+		return
+	}
+
+	var b [4]byte
+	binary.BigEndian.PutUint32(b[:], uint32(n)|1<<31)
+	buf.Write(b[:])
+}
@@ -0,0 +1,342 @@
+package fastcgi
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/raphaelreyna/graphqld/internal/limits"
+)
+
+type record struct {
+	recType byte
+	id      uint16
+	content []byte
+	padding int
+}
+
+// readRecord reads a record the way an application would.
+func readRecord(r io.Reader) (record, error) {
+	var header [8]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return record{}, fmt.Errorf("unable to read record header: %w", err)
+	}
+
+	if header[0] != version1 {
+		return record{}, fmt.Errorf("expected version %d, got %d", version1, header[0])
+	}
+
+	var (
+		length  = binary.BigEndian.Uint16(header[4:6])
+		padding = int(header[6])
+		content = make([]byte, int(length)+padding)
+	)
+	if _, err := io.ReadFull(r, content); err != nil {
+		return record{}, fmt.Errorf("unable to read record content: %w", err)
+	}
+
+	return record{
+		recType: header[1],
+		id:      binary.BigEndian.Uint16(header[2:4]),
+		content: content[:length],
+		padding: padding,
+	}, nil
+}
+
+// readStream reads the records of a stream up to the empty one ending it.
+func readStream(r io.Reader, recType byte) ([]byte, error) {
+	var data []byte
+	for {
+		rec, err := readRecord(r)
+		if err != nil {
+			return nil, err
+		}
+
+		if rec.recType != recType {
+			return nil, fmt.Errorf("expected a record of type %d, got %d", recType, rec.recType)
+		}
+
+		if len(rec.content) == 0 {
+			return data, nil
+		}
+		data = append(data, rec.content...)
+	}
+}
+
+// decodeParams decodes name-value pairs the way an application would.
+func decodeParams(data []byte) map[string]string {
+	var (
+		params = make(map[string]string)
+		length = func() int {
+			if data[0]>>7 == 0 {
+				n := int(data[0])
+				data = data[1:]
+				return n
+			}
+
+			n := int(binary.BigEndian.Uint32(data[:4]) &^ (1 << 31))
+			data = data[4:]
+			return n
+		}
+	)
+
+	for 0 < len(data) {
+		var (
+			kl = length()
+			vl = length()
+		)
+		params[string(data[:kl])] = string(data[kl : kl+vl])
+		data = data[kl+vl:]
+	}
+
+	return params
+}
+
+func TestWriteRecord(t *testing.T) {
+	var tests = []struct {
+		content string
+		padding int
+	}{
+		{content: "", padding: 0},
+		{content: "abc", padding: 5},
+		{content: "12345678", padding: 0},
+		{content: "123456789", padding: 7},
+	}
+
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		if err := writeRecord(&buf, typeStdin, []byte(tt.content)); err != nil {
+			t.Fatal(err)
+		}
+
+		if got, want := buf.Len(), 8+len(tt.content)+tt.padding; got != want {
+			t.Errorf("%q: expected %d bytes, got %d", tt.content, want, got)
+		}
+
+		rec, err := readRecord(&buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if (len(rec.content)+rec.padding)%8 != 0 {
+			t.Errorf("%q: record padded with %d bytes isn't aligned", tt.content, rec.padding)
+		}
+
+		if rec.recType != typeStdin || rec.id != requestID || string(rec.content) != tt.content || rec.padding != tt.padding {
+			t.Errorf("%q: unexpected record %+v", tt.content, rec)
+		}
+	}
+}
+
+func TestWriteStream(t *testing.T) {
+	var (
+		buf  bytes.Buffer
+		data = bytes.Repeat([]byte("x"), maxContentLength+10)
+	)
+
+	if err := writeStream(&buf, typeParams, data); err != nil {
+		t.Fatal(err)
+	}
+
+	var lengths []int
+	for 0 < buf.Len() {
+		rec, err := readRecord(&buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		lengths = append(lengths, len(rec.content))
+	}
+
+	if want := []int{maxContentLength, 10, 0}; !reflect.DeepEqual(lengths, want) {
+		t.Fatalf("expected records of %v bytes, got %v", want, lengths)
+	}
+}
+
+func TestEncodeParams(t *testing.T) {
+	var params = map[string]string{
+		"SCRIPT_FILENAME":        "/srv/index.php",
+		"EMPTY":                  "",
+		"LONG":                   strings.Repeat("v", 200),
+		strings.Repeat("K", 128): "short",
+	}
+
+	var data = encodeParams(params)
+	if got := decodeParams(data); !reflect.DeepEqual(got, params) {
+		t.Fatalf("expected %v, got %v", params, got)
+	}
+
+	// lengths under 128 take a single byte
+	if got := encodeParams(map[string]string{"A": "b"}); !bytes.Equal(got, []byte{1, 1, 'A', 'b'}) {
+		t.Errorf("unexpected encoding %v", got)
+	}
+}
+
+// serve accepts a single connection on l and answers its request with respond.
+func serve(l net.Listener, respond func(w io.Writer, params map[string]string, stdin []byte)) <-chan error {
+	var done = make(chan error, 1)
+
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			done <- err
+			return
+		}
+		defer conn.Close()
+
+		var r = bufio.NewReader(conn)
+
+		begin, err := readRecord(r)
+		if err != nil {
+			done <- err
+			return
+		}
+
+		if begin.recType != typeBeginRequest || binary.BigEndian.Uint16(begin.content[:2]) != roleResponder {
+			done <- errors.New("expected a responder begin request record")
+			return
+		}
+
+		params, err := readStream(r, typeParams)
+		if err != nil {
+			done <- err
+			return
+		}
+
+		stdin, err := readStream(r, typeStdin)
+		if err != nil {
+			done <- err
+			return
+		}
+
+		var w = bufio.NewWriter(conn)
+		respond(w, decodeParams(params), stdin)
+		done <- w.Flush()
+	}()
+
+	return done
+}
+
+func endRequest(w io.Writer, appStatus uint32, protocolStatus byte) {
+	var body [8]byte
+	binary.BigEndian.PutUint32(body[:4], appStatus)
+	body[4] = protocolStatus
+	writeRecord(w, typeEndRequest, body[:])
+}
+
+func TestClientDo(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	var done = serve(l, func(w io.Writer, params map[string]string, stdin []byte) {
+		writeRecord(w, typeStdout, []byte("Content-Type: text/plain\r\n\r\n"))
+		writeRecord(w, typeStderr, []byte("warning"))
+		writeRecord(w, typeStdout, []byte(params["NAME"]+" "+string(stdin)))
+		writeRecord(w, typeStdout, nil)
+		endRequest(w, 3, 0)
+	})
+
+	var c = NewClient("tcp:" + l.Addr().String())
+	resp, err := c.Do(context.Background(), map[string]string{"NAME": "ann"}, []byte(`{"id": 1}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+
+	var want = Response{
+		Stdout:    []byte("Content-Type: text/plain\r\n\r\nann {\"id\": 1}"),
+		Stderr:    []byte("warning"),
+		AppStatus: 3,
+	}
+	if !reflect.DeepEqual(*resp, want) {
+		t.Fatalf("expected %+v, got %+v", want, *resp)
+	}
+}
+
+func TestClientDoRejected(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	var done = serve(l, func(w io.Writer, params map[string]string, stdin []byte) {
+		// FCGI_OVERLOADED
+		endRequest(w, 0, 2)
+	})
+
+	_, err = NewClient(l.Addr().String()).Do(context.Background(), nil, nil)
+	if !errors.Is(err, ErrProtocol) {
+		t.Fatalf("expected a protocol error, got %v", err)
+	}
+
+	<-done
+}
+
+func TestClientDoMaxOutput(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	var tests = []struct {
+		name    string
+		recType byte
+	}{
+		{name: "stdout", recType: typeStdout},
+		{name: "stderr", recType: typeStderr},
+	}
+
+	for _, tt := range tests {
+		var done = serve(l, func(w io.Writer, params map[string]string, stdin []byte) {
+			writeRecord(w, tt.recType, []byte("12345"))
+			writeRecord(w, tt.recType, []byte("678"))
+			writeRecord(w, tt.recType, []byte("9"))
+			writeRecord(w, tt.recType, nil)
+			endRequest(w, 0, 0)
+		})
+
+		var c = NewClient(l.Addr().String())
+		c.MaxOutput = 8
+
+		_, err := c.Do(context.Background(), nil, nil)
+		if !errors.Is(err, limits.ErrOutputTooLarge) {
+			t.Errorf("%s: expected %v, got %v", tt.name, limits.ErrOutputTooLarge, err)
+		}
+
+		<-done
+	}
+}
+
+func TestNewClient(t *testing.T) {
+	var tests = []struct {
+		addr             string
+		network, address string
+	}{
+		{addr: "unix:/run/php.sock", network: "unix", address: "/run/php.sock"},
+		{addr: "/run/php.sock", network: "unix", address: "/run/php.sock"},
+		{addr: "tcp:127.0.0.1:9000", network: "tcp", address: "127.0.0.1:9000"},
+		{addr: "127.0.0.1:9000", network: "tcp", address: "127.0.0.1:9000"},
+	}
+
+	for _, tt := range tests {
+		c := NewClient(tt.addr)
+		if c.Network != tt.network || c.Address != tt.address {
+			t.Errorf("%s: expected %s %s, got %s %s", tt.addr, tt.network, tt.address, c.Network, c.Address)
+		}
+	}
+}
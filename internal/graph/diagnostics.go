@@ -0,0 +1,118 @@
+package graph
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/graphql-go/graphql/language/location"
+	"github.com/raphaelreyna/graphqld/internal/scan"
+)
+
+// Diagnostic is a problem found while building a graph, located in the file it comes from when there is one.
+type Diagnostic struct {
+	File   string `json:"file,omitempty"`
+	Line   int    `json:"line,omitempty"`
+	Column int    `json:"column,omitempty"`
+
+	Message string `json:"message"`
+}
+
+// Location returns where the problem is, as file:line:column or as much of it as is known.
+func (d Diagnostic) Location() string {
+	if d.Line == 0 {
+		return d.File
+	}
+
+	return fmt.Sprintf("%s:%d:%d", d.File, d.Line, d.Column)
+}
+
+func (d Diagnostic) String() string {
+	if d.File == "" {
+		return d.Message
+	}
+
+	return d.Location() + ": " + d.Message
+}
+
+// Diagnostics are the problems found while building a graph; Build fails with all of them at once.
+type Diagnostics []Diagnostic
+
+// Sorted returns the diagnostics ordered by file and position.
+func (ds Diagnostics) Sorted() Diagnostics {
+	var sorted = append(Diagnostics{}, ds...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		var a, b = sorted[i], sorted[j]
+		if a.File != b.File {
+			return a.File < b.File
+		}
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Column < b.Column
+	})
+
+	return sorted
+}
+
+func (ds Diagnostics) Error() string {
+	var b strings.Builder
+
+	if len(ds) == 1 {
+		b.WriteString("1 problem found in the graph:")
+	} else {
+		fmt.Fprintf(&b, "%d problems found in the graph:", len(ds))
+	}
+
+	for _, d := range ds.Sorted() {
+		b.WriteString("\n\t")
+		b.WriteString(d.String())
+	}
+
+	return b.String()
+}
+
+// at returns a Diagnostic located at loc.
+// Schema files are parsed with their path as the name of their source; fields listed by executables and descriptors
+// are parsed from a source made up of them, so only their file is known.
+func at(loc *ast.Location, format string, args ...interface{}) Diagnostic {
+	var d = Diagnostic{
+		Message: fmt.Sprintf(format, args...),
+	}
+
+	if loc == nil || loc.Source == nil {
+		return d
+	}
+
+	d.File = loc.Source.Name
+	if filepath.Ext(d.File) == ".graphql" {
+		l := location.GetLocation(loc.Source, loc.Start)
+		d.Line, d.Column = l.Line, l.Column
+	}
+
+	return d
+}
+
+// report records a problem with the graph.
+func (g *Graph) report(d Diagnostic) {
+	g.diagnostics = append(g.diagnostics, d)
+}
+
+// reportErr records err, located if it's a *scan.Error.
+func (g *Graph) reportErr(err error) {
+	var se *scan.Error
+	if errors.As(err, &se) {
+		g.report(Diagnostic{
+			File:    se.Path,
+			Line:    se.Line,
+			Column:  se.Column,
+			Message: se.Err.Error(),
+		})
+		return
+	}
+
+	g.report(Diagnostic{Message: err.Error()})
+}
@@ -2,30 +2,91 @@ package graph
 
 import (
 	"errors"
+	"fmt"
+	"strings"
 
 	"github.com/graphql-go/graphql"
 	"github.com/raphaelreyna/graphqld/internal/config"
 	"github.com/raphaelreyna/graphqld/internal/graph/resolver"
+	"github.com/raphaelreyna/graphqld/internal/limits"
+	"github.com/raphaelreyna/graphqld/internal/sandbox"
+	"github.com/raphaelreyna/graphqld/internal/scan"
 )
 
 var ErrorNoRoots = errors.New("no root query or mutation directories found")
 
 type definitions map[string]interface{}
-type resolverPaths map[string]map[string]string
+type resolverFiles map[string]map[string]scan.File
 type enums map[string]*graphql.Enum
+type scalars map[string]*graphql.Scalar
 type inputs map[string]*graphql.InputObject
 type objects map[string]*graphql.Object
+type abstracts map[string]graphql.Type
 
 type Graph struct {
 	DocumentRoot string
 	ResolverDir  string
 
-	Query    *graphql.Object
-	Mutation *graphql.Object
+	Query        *graphql.Object
+	Mutation     *graphql.Object
+	Subscription *graphql.Object
+
+	// Types lists every object, interface, union and declared scalar of the graph,
+	// so that types only reachable through an abstract type are part of the schema.
+	Types []graphql.Type
+
+	workerPools []*resolver.WorkerPool
+
+	// tape records or replays the resolvers run for the graph, if set
+	tape *resolver.Tape
+
+	// diagnostics are the problems found by the current build
+	diagnostics Diagnostics
 }
 
+// Build creates the graph from the files under its document root.
+// Problems with the graph are collected rather than stopping the build at the first one;
+// if there are any, Build returns them as Diagnostics.
 func (g *Graph) Build(c *config.GraphConf) error {
-	definitions, resolverPaths, err := g.scanForDefinitions()
+	// whatever was started for a graph that failed to build is stopped
+	if err := g.build(c); err != nil {
+		g.Close()
+		return err
+	}
+
+	return nil
+}
+
+func (g *Graph) build(c *config.GraphConf) error {
+	g.diagnostics = nil
+
+	// executables are run while scanning, so a graph that can't limit or sandbox them fails right away
+	if err := checkHelpers(c); err != nil {
+		return err
+	}
+
+	definitions, resolverFiles, err := g.scanForDefinitions(c)
+	if err != nil {
+		return err
+	}
+
+	// nothing is run in mock mode
+	if !c.Mock {
+		switch {
+		case c.Record != "" && c.Replay != "":
+			g.report(Diagnostic{File: c.Replay, Message: "a graph can't be recorded while being replayed"})
+		case c.Record != "":
+			if g.tape, err = resolver.NewRecordingTape(c.Record); err != nil {
+				g.report(Diagnostic{File: c.Record, Message: err.Error()})
+			}
+		case c.Replay != "":
+			if g.tape, err = resolver.NewReplayTape(c.Replay); err != nil {
+				g.report(Diagnostic{File: c.Replay, Message: err.Error()})
+			}
+		}
+	}
+
+	scalars, declaredScalars, err := g.instantiateScalars(definitions, c)
 	if err != nil {
 		return err
 	}
@@ -35,40 +96,233 @@ func (g *Graph) Build(c *config.GraphConf) error {
 		return err
 	}
 
-	inputs, err := g.instantiateInputs(definitions, enums)
+	inputs, err := g.instantiateInputs(definitions, enums, scalars)
 	if err != nil {
 		return err
 	}
 
-	objects, err := g.instantiateObjects(definitions, enums, inputs)
+	// the concrete type of an abstract value is given by its __typename or by the types resolve-type executable
+	var newResolveType = func(name string, objects objects) graphql.ResolveTypeFn {
+		var path string
+		if file, ok := resolverFiles[name][resolver.ResolveTypeName]; ok && !c.Mock {
+			path = file.Path()
+		}
+
+		return resolver.NewResolveTypeFn(name, path, func(name string) *graphql.Object {
+			return objects[name]
+		}, g.tape, c)
+	}
+
+	objects, abstracts, err := g.instantiateObjects(definitions, scalars, enums, inputs, newResolveType)
 	if err != nil {
 		return err
 	}
 
+	// unresolved types are left in the graph as placeholders, which can't be used
+	if 0 < len(g.diagnostics) {
+		return g.diagnostics.Sorted()
+	}
+
 	if q := objects["Query"]; 0 < len(q.Fields()) {
 		g.Query = q
 	}
 	if m := objects["Mutation"]; 0 < len(m.Fields()) {
 		g.Mutation = m
 	}
+	if s := objects["Subscription"]; 0 < len(s.Fields()) {
+		g.Subscription = s
+	}
 
 	if g.Query == nil && g.Mutation == nil {
-		return ErrorNoRoots
+		g.report(Diagnostic{
+			File:    g.DocumentRoot,
+			Message: ErrorNoRoots.Error() + "; a graph needs at least one Query or Mutation field",
+		})
+	}
+
+	for name, obj := range objects {
+		if name != "Query" && name != "Mutation" && name != "Subscription" {
+			g.Types = append(g.Types, obj)
+		}
+	}
+
+	for _, t := range abstracts {
+		g.Types = append(g.Types, t)
+	}
+
+	g.Types = append(g.Types, declaredScalars...)
+
+	// nothing is run in mock mode
+	if c.Mock {
+		g.mock(definitions, objects, abstracts)
+
+		if 0 < len(g.diagnostics) {
+			return g.diagnostics.Sorted()
+		}
+
+		return nil
 	}
 
-	for objName, resolverPaths := range resolverPaths {
-		var fields = objects[objName].Fields()
+	for objName, files := range resolverFiles {
+		obj, ok := objects[objName]
+		if !ok {
+			continue
+		}
+
+		var fields = obj.Fields()
+		for fieldName, file := range files {
+			if fieldName == resolver.ResolveTypeName {
+				continue
+			}
 
-		for fieldName, resolverPath := range resolverPaths {
 			var field = fields[fieldName]
-			resolver, err := resolver.NewFieldResolveFn(resolverPath, g.ResolverDir, field, c)
+
+			b, err := g.newBackend(file, c.Fields[objName+"."+fieldName], c)
 			if err != nil {
-				return err
+				g.report(Diagnostic{File: file.Path(), Message: err.Error()})
+				continue
 			}
 
-			field.Resolve = *resolver
+			if err := g.setResolver(objName, field, b, c); err != nil {
+				g.report(Diagnostic{File: file.Path(), Message: err.Error()})
+			}
 		}
 	}
 
+	// fields bound to a backend by the graph configuration take precedence over resolver files
+	for name, fc := range c.Fields {
+		if fc.FastCGI == nil && fc.Topic == nil {
+			continue
+		}
+
+		var parts = strings.SplitN(name, ".", 2)
+		if len(parts) != 2 {
+			g.report(Diagnostic{Message: fmt.Sprintf("invalid field name %q: expected Object.field", name)})
+			continue
+		}
+
+		var field *graphql.FieldDefinition
+		if obj, ok := objects[parts[0]]; ok {
+			field = obj.Fields()[parts[1]]
+		}
+		if field == nil {
+			g.report(Diagnostic{Message: fmt.Sprintf("resolver configured for unknown field %s", name)})
+			continue
+		}
+
+		var b resolver.Backend
+		switch {
+		case fc.Topic != nil:
+			if parts[0] != "Subscription" {
+				g.report(Diagnostic{Message: fmt.Sprintf("topic configured for %s: only Subscription fields can be bound to a topic", name)})
+				continue
+			}
+
+			var err error
+			if b, err = resolver.NewTopicBackend(*fc.Topic, fc, g.tape, c); err != nil {
+				g.report(Diagnostic{Message: fmt.Sprintf("%s: %v", name, err)})
+				continue
+			}
+		default:
+			b = resolver.NewFastCGIBackend(*fc.FastCGI, c.Limits.Merge(fc.Limits))
+		}
+
+		if err := g.setResolver(parts[0], field, b, c); err != nil {
+			g.report(Diagnostic{Message: fmt.Sprintf("%s: %v", name, err)})
+		}
+	}
+
+	if 0 < len(g.diagnostics) {
+		return g.diagnostics.Sorted()
+	}
+
+	return nil
+}
+
+// Schema creates the schema of a built graph.
+func (g *Graph) Schema() (graphql.Schema, error) {
+	return graphql.NewSchema(graphql.SchemaConfig{
+		Query:        g.Query,
+		Mutation:     g.Mutation,
+		Subscription: g.Subscription,
+		Types:        g.Types,
+	})
+}
+
+// checkHelpers reports whether the processes started for the graph can be limited and sandboxed as configured.
+func checkHelpers(c *config.GraphConf) error {
+	if p := c.Sandbox; p != nil {
+		if err := sandbox.Check(p); err != nil {
+			return fmt.Errorf("unable to sandbox resolvers: %w", err)
+		}
+	}
+
+	var ls = []config.Limits{c.Limits}
+	for _, fc := range c.Fields {
+		ls = append(ls, c.Limits.Merge(fc.Limits))
+	}
+
+	for _, l := range ls {
+		if err := limits.Check(l); err != nil {
+			return fmt.Errorf("unable to limit resolvers: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (g *Graph) newBackend(file scan.File, fc config.FieldConf, c *config.GraphConf) (resolver.Backend, error) {
+	var l = c.Limits.Merge(fc.Limits)
+
+	switch file := file.(type) {
+	case *scan.FastCGIFile:
+		return resolver.NewFastCGIBackend(file.Conf, l), nil
+	case *scan.HTTPFile:
+		return resolver.NewHTTPBackend(file.Path(), file.Conf)
+	default:
+
+		if wc := fc.Worker; wc != nil {
+			pool := resolver.NewWorkerPool(file.Path(), g.ResolverDir, *wc, l, c)
+			g.workerPools = append(g.workerPools, pool)
+
+			return pool, nil
+		}
+
+		if ef, ok := file.(*scan.ExecFile); ok && ef.Batch {
+			return resolver.NewBatchExecBackend(file.Path(), g.ResolverDir, l, g.tape, c), nil
+		}
+
+		return resolver.NewExecBackend(file.Path(), g.ResolverDir, l, g.tape, c), nil
+	}
+}
+
+func (g *Graph) setResolver(objName string, field *graphql.FieldDefinition, b resolver.Backend, c *config.GraphConf) error {
+	// a resolver that isn't replayed would run for real along with the replayed ones
+	if c.Replay != "" && g.tape != nil && !resolver.Taped(objName, b) {
+		return fmt.Errorf("resolver %s can't be replayed: only executables run once per field resolution are recorded", b)
+	}
+
+	resolver, err := resolver.NewFieldResolveFn(objName, field, b, c)
+	if err != nil {
+		return err
+	}
+
+	field.Resolve = *resolver
+
+	return nil
+}
+
+// Close stops any long-lived worker processes started for the graph.
+func (g *Graph) Close() error {
+	for _, pool := range g.workerPools {
+		pool.Close()
+	}
+	g.workerPools = nil
+
+	if g.tape != nil {
+		g.tape.Close()
+		g.tape = nil
+	}
+
 	return nil
 }
@@ -1,6 +1,7 @@
 package graph
 
 import (
+	"fmt"
 	"strings"
 
 	"github.com/graphql-go/graphql"
@@ -26,9 +27,11 @@ func (g *Graph) instantiateEnums(defs definitions) (enums, error) {
 			values = make(graphql.EnumValueConfigMap)
 		)
 
-		for idx, valueDef := range enum.Values {
+		// resolvers see values by name unless they're mapped to another representation
+		var seen = make(map[string]string, len(enum.Values))
+		for _, valueDef := range enum.Values {
 			var (
-				name        = valueDef.Name.Value
+				valueName   = valueDef.Name.Value
 				description string
 			)
 
@@ -36,8 +39,22 @@ func (g *Graph) instantiateEnums(defs definitions) (enums, error) {
 				description = d.Value
 			}
 
-			values[name] = &graphql.EnumValueConfig{
-				Value:       idx,
+			value, err := enumValue(valueDef)
+			if err != nil {
+				g.report(at(valueDef.Name.Loc, "enum %s: %v", name, err))
+				continue
+			}
+			if other, ok := seen[value]; ok {
+				g.report(at(valueDef.Name.Loc,
+					"enum %s: %s and %s are both represented by %q",
+					name, other, valueName, value,
+				))
+				continue
+			}
+			seen[value] = valueName
+
+			values[valueName] = &graphql.EnumValueConfig{
+				Value:       value,
 				Description: description,
 			}
 		}
@@ -46,14 +63,70 @@ func (g *Graph) instantiateEnums(defs definitions) (enums, error) {
 		if d := enum.Description; d != nil {
 			description = d.Value
 		}
-		enums[name] = graphql.NewEnum(graphql.EnumConfig{
+		e := graphql.NewEnum(graphql.EnumConfig{
 			Name:        name,
 			Values:      values,
 			Description: description,
 		})
+		if err := e.Error(); err != nil {
+			g.report(at(enum.Name.Loc, "enum %s: %v", name, err))
+		}
+
+		// the enums lookup tables are built lazily; build them now rather than racing to do so later
+		e.Serialize(nil)
+		e.ParseValue("")
+
+		enums[name] = e
 
 		delete(defs, k)
 	}
 
 	return enums, nil
 }
+
+// valueDirective maps an enum value to the representation resolvers use: RED @value(as: "r")
+const valueDirective = "value"
+
+// enumValue returns the representation of an enum value resolvers use, which is its name unless mapped with @value.
+func enumValue(def *ast.EnumValueDefinition) (string, error) {
+	for _, d := range def.Directives {
+		if d.Name.Value != valueDirective {
+			continue
+		}
+
+		for _, arg := range d.Arguments {
+			if arg.Name.Value != "as" {
+				continue
+			}
+
+			switch x := arg.Value.(type) {
+			case *ast.StringValue:
+				return x.Value, nil
+			case *ast.IntValue:
+				return x.Value, nil
+			case *ast.FloatValue:
+				return x.Value, nil
+			case *ast.BooleanValue:
+				return fmt.Sprint(x.Value), nil
+			}
+		}
+
+		return "", fmt.Errorf("%s: @%s requires a scalar as argument", def.Name.Value, valueDirective)
+	}
+
+	return def.Name.Value, nil
+}
+
+// enumDefault returns the default value of an argument or input field of enum type e in the representation
+// resolvers use, as defaults are taken from schema files by name.
+func enumDefault(e *graphql.Enum, v interface{}) interface{} {
+	if name, ok := v.(string); ok {
+		for _, value := range e.Values() {
+			if value.Name == name {
+				return value.Value
+			}
+		}
+	}
+
+	return v
+}
@@ -7,7 +7,7 @@ import (
 	"github.com/graphql-go/graphql/language/ast"
 )
 
-func (g *Graph) instantiateInputs(defs definitions, enums enums) (inputs, error) {
+func (g *Graph) instantiateInputs(defs definitions, enums enums, scalars scalars) (inputs, error) {
 	var (
 		unknowns = make([]*Unknown, 0)
 		inputs   = make(map[string]*graphql.InputObject)
@@ -71,14 +71,23 @@ func (g *Graph) instantiateInputs(defs definitions, enums enums) (inputs, error)
 			referencedName = u.Name()
 		)
 
+		if referenced, ok := scalars[referencedName]; ok {
+			referencer.Type = u.ModifyType(referenced)
+			continue
+		}
+
 		if referenced, ok := enums[referencedName]; ok {
 			referencer.Type = u.ModifyType(referenced)
+			referencer.DefaultValue = enumDefault(referenced, referencer.DefaultValue)
 			continue
 		}
 
 		if referenced, ok := inputs[referencedName]; ok {
 			referencer.Type = u.ModifyType(referenced)
+			continue
 		}
+
+		g.report(at(u.Loc, "unknown input type %s", referencedName))
 	}
 
 	return inputs, nil
@@ -7,13 +7,16 @@ import (
 	"github.com/graphql-go/graphql/language/ast"
 )
 
-func (g *Graph) instantiateObjects(defs definitions, enums enums, inputs inputs) (objects, error) {
+// instantiateObjects creates the objects, interfaces and unions of the graph;
+// newResolveType creates the function resolving the concrete type of values of an interface or union.
+func (g *Graph) instantiateObjects(defs definitions, scalars scalars, enums enums, inputs inputs, newResolveType func(string, objects) graphql.ResolveTypeFn) (objects, abstracts, error) {
 	var (
 		unknowns = make([]*Unknown, 0)
 
 		fieldsMap = map[string]graphql.Fields{
-			"Query":    make(graphql.Fields),
-			"Mutation": make(graphql.Fields),
+			"Query":        make(graphql.Fields),
+			"Mutation":     make(graphql.Fields),
+			"Subscription": make(graphql.Fields),
 		}
 
 		objects = objects{
@@ -29,9 +32,19 @@ func (g *Graph) instantiateObjects(defs definitions, enums enums, inputs inputs)
 					return fieldsMap["Mutation"]
 				}),
 			}),
+			"Subscription": graphql.NewObject(graphql.ObjectConfig{
+				Name: "Subscription",
+				Fields: graphql.FieldsThunk(func() graphql.Fields {
+					return fieldsMap["Subscription"]
+				}),
+			}),
 		}
+
+		interfaces = make(map[string]*graphql.Interface)
+		abstracts  = make(abstracts)
 	)
 
+	// interfaces come first so that objects can implement them
 	for k, v := range defs {
 		var (
 			parts   = strings.Split(k, "::")
@@ -39,59 +52,59 @@ func (g *Graph) instantiateObjects(defs definitions, enums enums, inputs inputs)
 			name    = parts[1]
 		)
 
-		if defType != "object" {
+		if defType != "iface" {
 			continue
 		}
 
 		var (
-			objDef = v.(*ast.ObjectDefinition)
-			fields = make(graphql.Fields)
+			ifaceDef = v.(*ast.InterfaceDefinition)
+			fields   = newFields(ifaceDef.Fields, &unknowns)
 		)
 
-		for _, field := range objDef.Fields {
-			var (
-				fieldConf = graphql.Field{
-					Name: name,
-				}
-
-				u *Unknown
-			)
-
-			if d := field.Description; d != nil {
-				fieldConf.Description = d.Value
-			}
+		var description string
+		if d := ifaceDef.Description; d != nil {
+			description = d.Value
+		}
 
-			fieldConf.Type, u = NewType(field.Type, &fieldConf)
-			if u != nil {
-				unknowns = append(unknowns, u)
-			}
+		iface := graphql.NewInterface(graphql.InterfaceConfig{
+			Name: name,
+			Fields: graphql.FieldsThunk(func() graphql.Fields {
+				return fields
+			}),
+			ResolveType: newResolveType(name, objects),
+			Description: description,
+		})
+		interfaces[name] = iface
+		abstracts[name] = iface
 
-			var argConfs = make(graphql.FieldConfigArgument)
-			for _, arg := range field.Arguments {
-				var (
-					argConf graphql.ArgumentConfig
-					u       *Unknown
-				)
+		delete(defs, k)
+	}
 
-				if d := arg.Description; d != nil {
-					argConf.Description = d.Value
-				}
+	for k, v := range defs {
+		var (
+			parts   = strings.Split(k, "::")
+			defType = parts[0]
+			name    = parts[1]
+		)
 
-				if d := arg.DefaultValue; d != nil {
-					argConf.DefaultValue = d.GetValue()
-				}
+		if defType != "object" {
+			continue
+		}
 
-				argConf.Type, u = NewType(arg.Type, &argConf)
-				if u != nil {
-					unknowns = append(unknowns, u)
-				}
+		var (
+			objDef = v.(*ast.ObjectDefinition)
+			fields = newFields(objDef.Fields, &unknowns)
+			ifaces = make([]*graphql.Interface, 0, len(objDef.Interfaces))
+		)
 
-				argConfs[arg.Name.Value] = &argConf
+		for _, named := range objDef.Interfaces {
+			iface, ok := interfaces[named.Name.Value]
+			if !ok {
+				g.report(at(named.Loc, "object %s implements unknown interface %s", name, named.Name.Value))
+				continue
 			}
 
-			fieldConf.Args = argConfs
-
-			fields[field.Name.Value] = &fieldConf
+			ifaces = append(ifaces, iface)
 		}
 
 		var description string
@@ -104,6 +117,7 @@ func (g *Graph) instantiateObjects(defs definitions, enums enums, inputs inputs)
 			Fields: graphql.FieldsThunk(func() graphql.Fields {
 				return fields
 			}),
+			Interfaces:  ifaces,
 			Description: description,
 		})
 
@@ -148,75 +162,151 @@ func (g *Graph) instantiateObjects(defs definitions, enums enums, inputs inputs)
 			unknowns = append(unknowns, u)
 		}
 
-		var argConfs = make(graphql.FieldConfigArgument)
-		for _, arg := range fieldDef.Arguments {
-			var (
-				argConf graphql.ArgumentConfig
-				u       *Unknown
-			)
+		var argConfs = newArgs(fieldDef.Arguments, &unknowns)
+		if len(argConfs) != 0 {
+			fieldConf.Args = argConfs
+		}
 
-			if d := arg.Description; d != nil {
-				argConf.Description = d.Value
-			}
+		fields := fieldsMap[obj.Name()]
+		fields[name] = &fieldConf
 
-			if d := arg.DefaultValue; d != nil {
-				argConf.DefaultValue = d.GetValue()
-			}
+		delete(defs, k)
+	}
+
+	// unions come last since they are made of objects
+	for k, v := range defs {
+		var (
+			parts   = strings.Split(k, "::")
+			defType = parts[0]
+			name    = parts[1]
+		)
+
+		if defType != "union" {
+			continue
+		}
 
-			argConf.Type, u = NewType(arg.Type, &argConf)
-			if u != nil {
-				unknowns = append(unknowns, u)
+		var (
+			unionDef = v.(*ast.UnionDefinition)
+			types    = make([]*graphql.Object, 0, len(unionDef.Types))
+		)
+
+		for _, named := range unionDef.Types {
+			obj, ok := objects[named.Name.Value]
+			if !ok {
+				g.report(at(named.Loc, "union %s includes unknown object %s", name, named.Name.Value))
+				continue
 			}
 
-			argConfs[arg.Name.Value] = &argConf
+			types = append(types, obj)
 		}
 
-		if len(argConfs) != 0 {
-			fieldConf.Args = argConfs
+		var description string
+		if d := unionDef.Description; d != nil {
+			description = d.Value
 		}
 
-		fields := fieldsMap[obj.Name()]
-		fields[name] = &fieldConf
+		abstracts[name] = graphql.NewUnion(graphql.UnionConfig{
+			Name:        name,
+			Types:       types,
+			ResolveType: newResolveType(name, objects),
+			Description: description,
+		})
 
 		delete(defs, k)
 	}
 
 	for _, u := range unknowns {
+		var (
+			referencedName = u.Name()
+			referenced     graphql.Type
+		)
+
+		if x, ok := scalars[referencedName]; ok {
+			referenced = x
+		} else if x, ok := enums[referencedName]; ok {
+			referenced = x
+		} else if x, ok := inputs[referencedName]; ok {
+			referenced = x
+		} else if x, ok := objects[referencedName]; ok {
+			referenced = x
+		} else if x, ok := abstracts[referencedName]; ok {
+			referenced = x
+		} else {
+			g.report(at(u.Loc, "unknown type %s", referencedName))
+			continue
+		}
+
 		switch referencer := u.Referencer.(type) {
 		case *graphql.Field:
-			var referencedName = u.Name()
-
-			if referenced, ok := enums[referencedName]; ok {
-				referencer.Type = u.ModifyType(referenced)
-				continue
+			referencer.Type = u.ModifyType(referenced)
+		case *graphql.ArgumentConfig:
+			referencer.Type = u.ModifyType(referenced)
+			if e, ok := referenced.(*graphql.Enum); ok {
+				referencer.DefaultValue = enumDefault(e, referencer.DefaultValue)
 			}
+		}
+	}
 
-			if referenced, ok := inputs[referencedName]; ok {
-				referencer.Type = u.ModifyType(referenced)
-				continue
-			}
+	return objects, abstracts, nil
+}
 
-			if referenced, ok := objects[referencedName]; ok {
-				referencer.Type = u.ModifyType(referenced)
-			}
-		case *graphql.ArgumentConfig:
-			var referencedName = u.Name()
+// newFields creates the fields of an object or interface from their definitions,
+// adding any reference to a type that isn't instantiated yet to unknowns.
+func newFields(defs []*ast.FieldDefinition, unknowns *[]*Unknown) graphql.Fields {
+	var fields = make(graphql.Fields)
 
-			if referenced, ok := enums[referencedName]; ok {
-				referencer.Type = u.ModifyType(referenced)
-				continue
+	for _, field := range defs {
+		var (
+			fieldConf = graphql.Field{
+				Name: field.Name.Value,
 			}
 
-			if referenced, ok := inputs[referencedName]; ok {
-				referencer.Type = u.ModifyType(referenced)
-				continue
-			}
+			u *Unknown
+		)
 
-			if referenced, ok := objects[referencedName]; ok {
-				referencer.Type = u.ModifyType(referenced)
-			}
+		if d := field.Description; d != nil {
+			fieldConf.Description = d.Value
 		}
+
+		fieldConf.Type, u = NewType(field.Type, &fieldConf)
+		if u != nil {
+			*unknowns = append(*unknowns, u)
+		}
+
+		fieldConf.Args = newArgs(field.Arguments, unknowns)
+
+		fields[field.Name.Value] = &fieldConf
+	}
+
+	return fields
+}
+
+// newArgs creates the arguments of a field from their definitions,
+// adding any reference to a type that isn't instantiated yet to unknowns.
+func newArgs(defs []*ast.InputValueDefinition, unknowns *[]*Unknown) graphql.FieldConfigArgument {
+	var argConfs = make(graphql.FieldConfigArgument)
+
+	for _, arg := range defs {
+		var (
+			argConf graphql.ArgumentConfig
+			u       *Unknown
+		)
+
+		if d := arg.Description; d != nil {
+			argConf.Description = d.Value
+		}
+
+		if d := arg.DefaultValue; d != nil {
+			argConf.DefaultValue = d.GetValue()
+		}
+
+		argConf.Type, u = NewType(arg.Type, &argConf)
+		if u != nil {
+			*unknowns = append(*unknowns, u)
+		}
+
+		argConfs[arg.Name.Value] = &argConf
 	}
 
-	return objects, nil
+	return argConfs
 }
@@ -0,0 +1,105 @@
+package graph
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/graphql-go/graphql/language/kinds"
+	"github.com/raphaelreyna/graphqld/internal/config"
+	"github.com/raphaelreyna/graphqld/internal/graph/resolver"
+	"github.com/raphaelreyna/graphqld/internal/scalar"
+)
+
+// coerceDirective names the executable coercing the values of a scalar: scalar Money @coerce(exec: "money.sh")
+const coerceDirective = "coerce"
+
+// instantiateScalars creates the scalars declared in the graph;
+// the library scalars are included as well so that they can be used without being declared.
+// It also returns the declared scalars.
+func (g *Graph) instantiateScalars(defs definitions, c *config.GraphConf) (scalars, []graphql.Type, error) {
+	var (
+		scalars  = make(scalars, len(scalar.Library))
+		declared = make([]graphql.Type, 0)
+	)
+
+	for name, s := range scalar.Library {
+		scalars[name] = s
+	}
+
+	for k, v := range defs {
+		var (
+			parts   = strings.Split(k, "::")
+			defType = parts[0]
+			name    = parts[1]
+		)
+
+		if defType != "scalar" {
+			continue
+		}
+
+		var scalarDef = v.(*ast.ScalarDefinition)
+
+		switch name {
+		case "String", "Int", "Float", "Boolean", "ID", "DateTime":
+			g.report(at(scalarDef.Name.Loc, "scalar %s redefines a built-in scalar", name))
+			delete(defs, k)
+			continue
+		}
+
+		path, err := coercerPath(scalarDef, g.DocumentRoot)
+		if err != nil {
+			g.report(at(scalarDef.Name.Loc, "%v", err))
+		}
+
+		var description string
+		if d := scalarDef.Description; d != nil {
+			description = d.Value
+		}
+
+		var s *graphql.Scalar
+		switch lib, ok := scalar.Library[name]; {
+		case path != "" && !c.Mock:
+			s = resolver.NewExecScalar(name, description, path, g.tape, c)
+		case ok:
+			s = lib
+		default:
+			s = scalar.NewPassthrough(name, description)
+		}
+
+		scalars[name] = s
+		declared = append(declared, s)
+
+		delete(defs, k)
+	}
+
+	return scalars, declared, nil
+}
+
+// coercerPath returns the path of the executable named by the @coerce directive of a scalar, if any.
+func coercerPath(def *ast.ScalarDefinition, documentRoot string) (string, error) {
+	for _, d := range def.Directives {
+		if d.Name.Value != coerceDirective {
+			continue
+		}
+
+		for _, arg := range d.Arguments {
+			if arg.Name.Value != "exec" || arg.Value.GetKind() != kinds.StringValue {
+				continue
+			}
+
+			var path = arg.Value.GetValue().(string)
+			if !filepath.IsAbs(path) {
+				path = filepath.Join(documentRoot, path)
+			}
+
+			return path, nil
+		}
+
+		return "", fmt.Errorf("scalar %s: @%s requires an exec string argument", def.Name.Value, coerceDirective)
+	}
+
+	return "", nil
+}
@@ -0,0 +1,107 @@
+package graph
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"github.com/graphql-go/graphql"
+)
+
+// introspectionQuery is the query tools such as code generators use to introspect a schema.
+const introspectionQuery = `
+query IntrospectionQuery {
+  __schema {
+    queryType { name }
+    mutationType { name }
+    subscriptionType { name }
+    types { ...FullType }
+    directives {
+      name
+      description
+      locations
+      args { ...InputValue }
+    }
+  }
+}
+
+fragment FullType on __Type {
+  kind
+  name
+  description
+  fields(includeDeprecated: true) {
+    name
+    description
+    args { ...InputValue }
+    type { ...TypeRef }
+    isDeprecated
+    deprecationReason
+  }
+  inputFields { ...InputValue }
+  interfaces { ...TypeRef }
+  enumValues(includeDeprecated: true) {
+    name
+    description
+    isDeprecated
+    deprecationReason
+  }
+  possibleTypes { ...TypeRef }
+}
+
+fragment InputValue on __InputValue {
+  name
+  description
+  type { ...TypeRef }
+  defaultValue
+}
+
+fragment TypeRef on __Type {
+  kind
+  name
+  ofType {
+    kind
+    name
+    ofType {
+      kind
+      name
+      ofType {
+        kind
+        name
+        ofType {
+          kind
+          name
+          ofType {
+            kind
+            name
+            ofType {
+              kind
+              name
+              ofType {
+                kind
+                name
+              }
+            }
+          }
+        }
+      }
+    }
+  }
+}
+`
+
+// Introspect returns the result of the introspection query against schema as JSON, in the {"data": {"__schema": ...}}
+// form schema registries and code generators accept.
+func Introspect(schema graphql.Schema) ([]byte, error) {
+	var result = graphql.Do(graphql.Params{
+		Schema:        schema,
+		RequestString: introspectionQuery,
+		Context:       context.Background(),
+	})
+	if result.HasErrors() {
+		return nil, errors.New(result.Errors[0].Message)
+	}
+
+	return json.Marshal(struct {
+		Data interface{} `json:"data"`
+	}{result.Data})
+}
@@ -0,0 +1,266 @@
+package graph
+
+import (
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/graphql-go/graphql"
+	"github.com/raphaelreyna/graphqld/internal/scan"
+)
+
+// mockListLength is how many elements mocked lists have.
+const mockListLength = 2
+
+// mockEpoch is the earliest time mocked dates and times are at.
+var mockEpoch = time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// mock resolves every field of objects with a value made up from its type, unless a mock file gives its value.
+// Values are derived from where they are in the response, so the same query always gets the same response.
+func (g *Graph) mock(defs definitions, objects objects, abstracts abstracts) {
+	var (
+		fixtures        = make(map[string]*scan.MockFile)
+		implementations = make(map[string][]*graphql.Object)
+	)
+
+	for k, v := range defs {
+		var parts = strings.Split(k, "::")
+		if parts[0] != "mock" {
+			continue
+		}
+
+		var file = v.(*scan.MockFile)
+		fixtures[strings.Replace(parts[1], ":", ".", 1)] = file
+
+		delete(defs, k)
+	}
+
+	// abstract values are mocked as the first of their possible types
+	for name, t := range abstracts {
+		var types []*graphql.Object
+
+		switch t := t.(type) {
+		case *graphql.Union:
+			types = append(types, t.Types()...)
+		case *graphql.Interface:
+			for _, obj := range objects {
+				for _, iface := range obj.Interfaces() {
+					if iface.Name() == name {
+						types = append(types, obj)
+					}
+				}
+			}
+		}
+
+		sort.Slice(types, func(i, j int) bool {
+			return types[i].Name() < types[j].Name()
+		})
+		implementations[name] = types
+	}
+
+	var m = mocker{implementations: implementations}
+
+	for objName, obj := range objects {
+		for fieldName, field := range obj.Fields() {
+			var key = objName + "." + fieldName
+
+			file, ok := fixtures[key]
+			if ok {
+				delete(fixtures, key)
+			}
+
+			field.Resolve = m.newResolveFn(field, file)
+		}
+	}
+
+	for key, file := range fixtures {
+		g.report(Diagnostic{
+			File:    file.Path(),
+			Message: fmt.Sprintf("mock value for unknown field %s", key),
+		})
+	}
+}
+
+type mocker struct {
+	implementations map[string][]*graphql.Object
+}
+
+// newResolveFn returns the resolver of field in mock mode: its value is taken from its parent if the parent was given
+// by a mock file, otherwise from the fields own mock file if it has one, otherwise it's made up.
+func (m mocker) newResolveFn(field *graphql.FieldDefinition, file *scan.MockFile) graphql.FieldResolveFn {
+	return func(p graphql.ResolveParams) (interface{}, error) {
+		if source, ok := p.Source.(map[string]interface{}); ok {
+			if v, ok := source[field.Name]; ok {
+				return m.fromFixture(field.Type, v), nil
+			}
+		}
+
+		if file != nil {
+			return m.fromFixture(field.Type, file.Value), nil
+		}
+
+		var path = make([]string, 0)
+		for _, key := range p.Info.Path.AsArray() {
+			path = append(path, fmt.Sprint(key))
+		}
+
+		return m.value(field.Type, field.Name, strings.Join(path, ".")), nil
+	}
+}
+
+// value makes up a value of type t for the field fieldName at path.
+// Objects are made up as empty objects whose fields are in turn resolved by their own resolvers.
+func (m mocker) value(t graphql.Type, fieldName, path string) interface{} {
+	var n = mockHash(path)
+
+	switch t := t.(type) {
+	case *graphql.NonNull:
+		return m.value(t.OfType, fieldName, path)
+	case *graphql.List:
+		var l = make([]interface{}, mockListLength)
+		for idx := range l {
+			l[idx] = m.value(t.OfType, fieldName, fmt.Sprintf("%s.%d", path, idx))
+		}
+		return l
+	case *graphql.Enum:
+		var values = append([]*graphql.EnumValueDefinition{}, t.Values()...)
+		if len(values) == 0 {
+			return nil
+		}
+		sort.Slice(values, func(i, j int) bool {
+			return values[i].Name < values[j].Name
+		})
+		return values[n%uint32(len(values))].Value
+	case *graphql.Object:
+		return map[string]interface{}{}
+	case *graphql.Interface, *graphql.Union:
+		var types = m.implementations[t.Name()]
+		if len(types) == 0 {
+			return nil
+		}
+		return map[string]interface{}{
+			"__typename": types[0].Name(),
+		}
+	case *graphql.Scalar:
+		return mockScalar(t, fieldName, n)
+	}
+
+	return nil
+}
+
+// fromFixture returns v, a value of type t decoded from a mock file, as its resolver would:
+// enums are given by name and abstract values default to the first of their possible types having all of their fields.
+func (m mocker) fromFixture(t graphql.Type, v interface{}) interface{} {
+	if v == nil {
+		return nil
+	}
+
+	switch t := t.(type) {
+	case *graphql.NonNull:
+		return m.fromFixture(t.OfType, v)
+	case *graphql.List:
+		l, ok := v.([]interface{})
+		if !ok {
+			return v
+		}
+
+		var values = make([]interface{}, len(l))
+		for idx, item := range l {
+			values[idx] = m.fromFixture(t.OfType, item)
+		}
+		return values
+	case *graphql.Enum:
+		for _, value := range t.Values() {
+			if value.Name == v {
+				return value.Value
+			}
+		}
+	case *graphql.Interface, *graphql.Union:
+		obj, ok := v.(map[string]interface{})
+		if !ok {
+			break
+		}
+
+		if _, ok := obj["__typename"]; ok {
+			break
+		}
+
+		for _, typ := range m.implementations[t.Name()] {
+			if !hasFields(typ, obj) {
+				continue
+			}
+
+			// mock files are shared by every request
+			var typed = make(map[string]interface{}, len(obj)+1)
+			for k, v := range obj {
+				typed[k] = v
+			}
+			typed["__typename"] = typ.Name()
+
+			return typed
+		}
+	}
+
+	return v
+}
+
+func hasFields(obj *graphql.Object, value map[string]interface{}) bool {
+	var fields = obj.Fields()
+	for k := range value {
+		if _, ok := fields[k]; !ok {
+			return false
+		}
+	}
+
+	return true
+}
+
+func mockScalar(s *graphql.Scalar, fieldName string, n uint32) interface{} {
+	switch s.Name() {
+	case "Int", "Long":
+		return int(n % 100)
+	case "Float":
+		return float64(n%10000) / 100
+	case "Boolean":
+		return n%2 == 0
+	case "ID":
+		return fmt.Sprint(n)
+	case "BigInt":
+		return fmt.Sprintf("%d000000000000", n)
+	case "DateTime":
+		return mockEpoch.Add(time.Duration(n%(365*24)) * time.Hour)
+	case "Date":
+		return mockEpoch.AddDate(0, 0, int(n%365)).Format("2006-01-02")
+	case "Time":
+		return mockEpoch.Add(time.Duration(n%(24*60)) * time.Minute).Format("15:04:05")
+	case "URL":
+		return fmt.Sprintf("https://example.com/%s/%d", fieldName, n%100)
+	case "Email":
+		return fmt.Sprintf("%s%d@example.com", fieldName, n%100)
+	case "UUID":
+		return fmt.Sprintf("%08x-0000-4000-8000-%012x", n, n)
+	case "JSON":
+		return map[string]interface{}{}
+	case "Upload":
+		return nil
+	default:
+		return fmt.Sprintf("%s %d", fieldName, n%100)
+	}
+}
+
+func mockHash(path string) uint32 {
+	var h = fnv.New32a()
+	h.Write([]byte(path))
+
+	// the low bits of FNV hashes barely change between similar paths, so they're mixed in with the high bits
+	var n = h.Sum32()
+	n ^= n >> 16
+	n *= 0x85ebca6b
+	n ^= n >> 13
+	n *= 0xc2b2ae35
+	n ^= n >> 16
+
+	return n
+}
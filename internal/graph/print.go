@@ -0,0 +1,298 @@
+package graph
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/graphql-go/graphql/language/printer"
+)
+
+// PrintSchema returns the SDL of schema. The introspection types and the scalars every GraphQL implementation provides
+// are left out; types, fields, arguments and enum values are printed in alphabetical order as the schema doesn't keep
+// the order they were defined in.
+func PrintSchema(schema graphql.Schema) string {
+	var (
+		typeMap = schema.TypeMap()
+		names   = make([]string, 0, len(typeMap))
+		blocks  = make([]string, 0, len(typeMap))
+	)
+
+	for name := range typeMap {
+		if strings.HasPrefix(name, "__") {
+			continue
+		}
+
+		switch name {
+		case "String", "Int", "Float", "Boolean", "ID":
+			continue
+		}
+
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if block := printType(typeMap[name]); block != "" {
+			blocks = append(blocks, block)
+		}
+	}
+
+	return strings.Join(blocks, "\n\n") + "\n"
+}
+
+func printType(t graphql.Type) string {
+	var b strings.Builder
+
+	printDescription(&b, "", t.Description())
+
+	switch t := t.(type) {
+	case *graphql.Scalar:
+		fmt.Fprintf(&b, "scalar %s", t.Name())
+	case *graphql.Enum:
+		var values = append([]*graphql.EnumValueDefinition{}, t.Values()...)
+		sort.Slice(values, func(i, j int) bool {
+			return values[i].Name < values[j].Name
+		})
+
+		fmt.Fprintf(&b, "enum %s {\n", t.Name())
+		for _, v := range values {
+			printDescription(&b, "  ", v.Description)
+			fmt.Fprintf(&b, "  %s%s\n", v.Name, printDeprecated(v.DeprecationReason))
+		}
+		b.WriteString("}")
+	case *graphql.InputObject:
+		var fields = t.Fields()
+
+		fmt.Fprintf(&b, "input %s {\n", t.Name())
+		for _, name := range sortedKeys(fields) {
+			var f = fields[name]
+
+			printDescription(&b, "  ", f.Description())
+			fmt.Fprintf(&b, "  %s\n", printInputValue(f.Name(), f.Type, f.DefaultValue))
+		}
+		b.WriteString("}")
+	case *graphql.Interface:
+		fmt.Fprintf(&b, "interface %s {\n", t.Name())
+		printFields(&b, t.Fields())
+		b.WriteString("}")
+	case *graphql.Union:
+		var types = make([]string, 0, len(t.Types()))
+		for _, obj := range t.Types() {
+			types = append(types, obj.Name())
+		}
+		sort.Strings(types)
+
+		fmt.Fprintf(&b, "union %s = %s", t.Name(), strings.Join(types, " | "))
+	case *graphql.Object:
+		var fields = t.Fields()
+		if len(fields) == 0 {
+			// unused root objects
+			return ""
+		}
+
+		fmt.Fprintf(&b, "type %s", t.Name())
+		if ifaces := t.Interfaces(); 0 < len(ifaces) {
+			var names = make([]string, 0, len(ifaces))
+			for _, iface := range ifaces {
+				names = append(names, iface.Name())
+			}
+			sort.Strings(names)
+
+			fmt.Fprintf(&b, " implements %s", strings.Join(names, " & "))
+		}
+		b.WriteString(" {\n")
+		printFields(&b, fields)
+		b.WriteString("}")
+	default:
+		return ""
+	}
+
+	return b.String()
+}
+
+func printFields(b *strings.Builder, fields graphql.FieldDefinitionMap) {
+	for _, name := range sortedKeys(fields) {
+		var f = fields[name]
+
+		printDescription(b, "  ", f.Description)
+		fmt.Fprintf(b, "  %s%s: %s%s\n", f.Name, printArgs(f.Args), f.Type, printDeprecated(f.DeprecationReason))
+	}
+}
+
+// printArgs prints arguments on one line, unless any of them has a description.
+func printArgs(args []*graphql.Argument) string {
+	if len(args) == 0 {
+		return ""
+	}
+
+	args = append([]*graphql.Argument{}, args...)
+	sort.Slice(args, func(i, j int) bool {
+		return args[i].Name() < args[j].Name()
+	})
+
+	var described bool
+	for _, arg := range args {
+		described = described || arg.Description() != ""
+	}
+
+	var b strings.Builder
+	if !described {
+		b.WriteString("(")
+		for idx, arg := range args {
+			if idx != 0 {
+				b.WriteString(", ")
+			}
+			b.WriteString(printInputValue(arg.Name(), arg.Type, arg.DefaultValue))
+		}
+		b.WriteString(")")
+
+		return b.String()
+	}
+
+	b.WriteString("(\n")
+	for _, arg := range args {
+		printDescription(&b, "    ", arg.Description())
+		fmt.Fprintf(&b, "    %s\n", printInputValue(arg.Name(), arg.Type, arg.DefaultValue))
+	}
+	b.WriteString("  )")
+
+	return b.String()
+}
+
+func printInputValue(name string, t graphql.Input, defaultValue interface{}) string {
+	if defaultValue == nil {
+		return fmt.Sprintf("%s: %s", name, t)
+	}
+
+	return fmt.Sprintf("%s: %s = %s", name, t, printValue(defaultValue, t))
+}
+
+// printValue prints v, a value of type t, as a GraphQL literal.
+func printValue(v interface{}, t graphql.Type) string {
+	if v == nil {
+		return "null"
+	}
+
+	// list defaults are kept as they were parsed
+	if x, ok := v.(ast.Value); ok {
+		if s, ok := printer.Print(x).(string); ok {
+			return s
+		}
+	}
+
+	switch t := t.(type) {
+	case *graphql.NonNull:
+		return printValue(v, t.OfType)
+	case *graphql.List:
+		items, ok := v.([]interface{})
+		if !ok {
+			return printValue(v, t.OfType)
+		}
+
+		var printed = make([]string, len(items))
+		for idx, item := range items {
+			printed[idx] = printValue(item, t.OfType)
+		}
+
+		return "[" + strings.Join(printed, ", ") + "]"
+	case *graphql.Enum:
+		for _, value := range t.Values() {
+			if value.Value == v {
+				return value.Name
+			}
+		}
+
+		return fmt.Sprint(v)
+	case *graphql.InputObject:
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			break
+		}
+
+		var (
+			fields  = t.Fields()
+			printed = make([]string, 0, len(m))
+		)
+		for _, name := range sortedKeys(m) {
+			var ft graphql.Type
+			if f, ok := fields[name]; ok {
+				ft = f.Type
+			}
+
+			printed = append(printed, fmt.Sprintf("%s: %s", name, printValue(m[name], ft)))
+		}
+
+		return "{" + strings.Join(printed, ", ") + "}"
+	case *graphql.Scalar:
+		// integer and float defaults are kept as the text they were parsed from
+		if s, ok := v.(string); ok && (t == graphql.Int || t == graphql.Float) {
+			return s
+		}
+	}
+
+	if s, ok := v.(string); ok {
+		data, _ := json.Marshal(s)
+		return string(data)
+	}
+
+	if data, err := json.Marshal(v); err == nil {
+		return string(data)
+	}
+
+	return fmt.Sprint(v)
+}
+
+func printDescription(b *strings.Builder, indent, description string) {
+	if description == "" {
+		return
+	}
+
+	var lines = strings.Split(strings.ReplaceAll(description, `"""`, `\"""`), "\n")
+
+	b.WriteString(indent + `"""` + "\n")
+	for _, line := range lines {
+		if line == "" {
+			b.WriteString("\n")
+			continue
+		}
+		b.WriteString(indent + line + "\n")
+	}
+	b.WriteString(indent + `"""` + "\n")
+}
+
+func printDeprecated(reason string) string {
+	if reason == "" {
+		return ""
+	}
+
+	data, _ := json.Marshal(reason)
+
+	return fmt.Sprintf(" @deprecated(reason: %s)", data)
+}
+
+// sortedKeys returns the keys of a map with string keys in order.
+func sortedKeys(m interface{}) []string {
+	var keys []string
+
+	switch m := m.(type) {
+	case graphql.FieldDefinitionMap:
+		for k := range m {
+			keys = append(keys, k)
+		}
+	case graphql.InputObjectFieldMap:
+		for k := range m {
+			keys = append(keys, k)
+		}
+	case map[string]interface{}:
+		for k := range m {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	return keys
+}
@@ -0,0 +1,253 @@
+package resolver
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"sort"
+	"syscall"
+
+	"github.com/graphql-go/graphql"
+	"github.com/raphaelreyna/graphqld/internal/config"
+	"github.com/raphaelreyna/graphqld/internal/middleware"
+	"github.com/raphaelreyna/graphqld/internal/sandbox"
+)
+
+// Backend runs a fields resolver.
+type Backend interface {
+	run(ctx context.Context, inv *invocation) (*output, error)
+
+	// String describes the resolver for logging.
+	String() string
+}
+
+// invocation holds everything a backend needs to resolve a field once.
+type invocation struct {
+	objName, fieldName string
+
+	// args maps each argument name to its string form.
+	args map[string]string
+
+	params graphql.ResolveParams
+}
+
+// source returns the invocations source encoded as JSON, or nil if there is no source.
+func (inv *invocation) source() ([]byte, error) {
+	if inv.params.Source == nil {
+		return nil, nil
+	}
+
+	data, err := json.Marshal(inv.params.Source)
+	if err != nil {
+		return nil, fmt.Errorf("error encoding resolver source as JSON: %w", err)
+	}
+
+	return data, nil
+}
+
+// info returns what the resolver is told about the field its resolving.
+func (inv *invocation) info() *resolveInfo {
+	return newResolveInfo(inv.params.Info)
+}
+
+// output is what a resolver produced; header is added to the HTTP response
+// and body is handed to the fields output parser.
+// errs are reported alongside the (partial) data in body.
+type output struct {
+	header http.Header
+	body   []byte
+	errs   reportedErrors
+}
+
+// dataBody returns JSON data from a resolver the way a one-shot resolver would have
+// written it to stdout; strings are passed through, anything else as JSON.
+func dataBody(data json.RawMessage) []byte {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		return []byte(s)
+	}
+
+	if len(data) == 0 || string(data) == "null" {
+		return nil
+	}
+
+	return data
+}
+
+// splitOutput splits raw CGI style output into its MIME header and body.
+// Output without a blank line is all body.
+func splitOutput(data []byte) (*output, error) {
+	var (
+		out = output{body: data}
+		idx = bytes.Index(data, []byte("\n\n"))
+		sep = 2
+	)
+
+	if crlf := bytes.Index(data, []byte("\r\n\r\n")); crlf != -1 && (idx == -1 || crlf < idx) {
+		idx, sep = crlf, 4
+	}
+
+	if idx == -1 {
+		return &out, nil
+	}
+
+	out.body = data[idx+sep:]
+	tpReader := textproto.NewReader(
+		bufio.NewReader(
+			bytes.NewReader(data[:idx+sep]),
+		),
+	)
+
+	header, err := tpReader.ReadMIMEHeader()
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("unable to read MIME Header from resolver output: %w", err)
+	}
+	out.header = http.Header(header)
+
+	return &out, nil
+}
+
+type execBackend struct {
+	path, wd string
+	user     *config.User
+	sandbox  *sandbox.Profile
+	limits   config.Limits
+	tape     *Tape
+}
+
+// NewExecBackend returns a Backend that runs the executable at path once per field resolution,
+// with the resource limits l. Its runs are recorded to or replayed from t, if it's not nil.
+func NewExecBackend(path, wd string, l config.Limits, t *Tape, c *config.GraphConf) Backend {
+	return &execBackend{
+		path:    path,
+		wd:      wd,
+		user:    c.User,
+		sandbox: c.Sandbox,
+		limits:  l,
+		tape:    t,
+	}
+}
+
+func (eb *execBackend) String() string {
+	return eb.path
+}
+
+// command prepares the process resolving inv; the files it is handed are closed by closeFiles,
+// errFile being the one the resolver may write errors to.
+func (eb *execBackend) command(ctx context.Context, inv *invocation) (cmd *command, errFile *os.File, closeFiles func(), err error) {
+	var names = make([]string, 0, len(inv.args))
+	for name := range inv.args {
+		names = append(names, name)
+	}
+	// in the same order every time, so that runs can be replayed
+	sort.Strings(names)
+
+	var args = make([]string, 0, 2*len(inv.args))
+	for _, name := range names {
+		args = append(args, "--"+name, inv.args[name])
+	}
+
+	cmd = newCommand(ctx, eb.path, args...)
+	cmd.limits = eb.limits
+	cmd.sandbox = eb.sandbox
+
+	// uploaded files have to be readable from within the sandbox
+	if dir := middleware.GetUploadDir(ctx); dir != "" && cmd.sandbox != nil {
+		var p = *cmd.sandbox
+		p.Binds = append(append([]string{}, p.Binds...), dir)
+		cmd.sandbox = &p
+	}
+
+	source, err := inv.source()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if source != nil {
+		cmd.Stdin = bytes.NewReader(source)
+	}
+
+	if user := eb.user; user != nil {
+		cmd.SysProcAttr.Credential = &syscall.Credential{
+			Uid: user.Uid,
+			Gid: user.Gid,
+		}
+	}
+
+	var info = inv.info()
+
+	env := append([]string{}, middleware.GetEnv(ctx)...)
+	env = append(env,
+		"SCRIPT_NAME="+filepath.Base(eb.path),
+		"SCRIPT_FILENAME="+eb.path,
+	)
+	cmd.Env = append(env, info.env()...)
+
+	errFile, err = newTempFile("graphqld-errors-")
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	infoFile, err := info.newInfoFile()
+	if err != nil {
+		errFile.Close()
+		return nil, nil, nil, err
+	}
+
+	// the context file is at fd 3 (if there is one), the errors file at fd 4
+	// and the resolve info at fd 5
+	cmd.ExtraFiles = []*os.File{middleware.GetCtxFile(ctx), errFile, infoFile}
+
+	if eb.wd != "" {
+		cmd.Dir = eb.wd
+	}
+
+	closeFiles = func() {
+		errFile.Close()
+		infoFile.Close()
+	}
+
+	return cmd, errFile, closeFiles, nil
+}
+
+func (eb *execBackend) run(ctx context.Context, inv *invocation) (*output, error) {
+	cmd, errFile, closeFiles, err := eb.command(ctx, inv)
+	if err != nil {
+		return nil, err
+	}
+	defer closeFiles()
+
+	cmd.tape = eb.tape
+	cmd.errFile = errFile
+
+	data, runErr := cmd.output()
+
+	errs, err := readErrorFile(errFile)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read errors file: %w", err)
+	}
+
+	if runErr != nil {
+		var exitErr *exitError
+		if errors.As(runErr, &exitErr) && 0 < len(errs) {
+			exitErr.errs = errs
+		}
+
+		return nil, runErr
+	}
+
+	out, err := splitOutput(data)
+	if err != nil {
+		return nil, err
+	}
+	out.errs = errs
+
+	return out, nil
+}
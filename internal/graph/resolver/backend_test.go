@@ -0,0 +1,76 @@
+package resolver
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestSplitOutput(t *testing.T) {
+	var tests = []struct {
+		name string
+		data string
+
+		header http.Header
+		body   string
+		err    bool
+	}{
+		{name: "body only", data: "hello\n", body: "hello\n"},
+		{name: "empty", data: "", body: ""},
+		{
+			name:   "header",
+			data:   "X-A: 1\nX-A: 2\n\nbody\n",
+			header: http.Header{"X-A": {"1", "2"}},
+			body:   "body\n",
+		},
+		{
+			name:   "crlf header",
+			data:   "X-A: 1\r\nset-cookie: a=b\r\n\r\nbody\r\n",
+			header: http.Header{"X-A": {"1"}, "Set-Cookie": {"a=b"}},
+			body:   "body\r\n",
+		},
+		{
+			name:   "crlf header with blank lines in the body",
+			data:   "X-A: 1\r\n\r\none\n\ntwo",
+			header: http.Header{"X-A": {"1"}},
+			body:   "one\n\ntwo",
+		},
+		{
+			name:   "lf header with crlf blank lines in the body",
+			data:   "X-A: 1\n\none\r\n\r\ntwo",
+			header: http.Header{"X-A": {"1"}},
+			body:   "one\r\n\r\ntwo",
+		},
+		{
+			name:   "header without a body",
+			data:   "Status: 404 Not Found\r\n\r\n",
+			header: http.Header{"Status": {"404 Not Found"}},
+			body:   "",
+		},
+		{name: "malformed header", data: "not a header\n\nbody", err: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := splitOutput([]byte(tt.data))
+			if tt.err {
+				if err == nil {
+					t.Fatalf("expected an error, got header %v and body %q", out.header, out.body)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(out.header, tt.header) {
+				t.Errorf("expected header %v, got %v", tt.header, out.header)
+			}
+
+			if string(out.body) != tt.body {
+				t.Errorf("expected body %q, got %q", tt.body, out.body)
+			}
+		})
+	}
+}
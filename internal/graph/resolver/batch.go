@@ -0,0 +1,288 @@
+package resolver
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"syscall"
+
+	"github.com/raphaelreyna/graphqld/internal/config"
+	"github.com/raphaelreyna/graphqld/internal/middleware"
+)
+
+// batchBackend is implemented by backends that resolve many invocations of a field at once.
+type batchBackend interface {
+	Backend
+
+	// runBatch returns one result per invocation, in the same order.
+	runBatch(ctx context.Context, invs []*invocation) (http.Header, []batchResult, error)
+}
+
+type batchResult struct {
+	body []byte
+	err  error
+}
+
+// loader collects the invocations of a field made while resolving a single level of a request
+// and hands them to its backend all at once when the first of their results is needed.
+type loader struct {
+	b           batchBackend
+	withTimeout timeoutFunc
+	describeErr errFunc
+
+	sync.Mutex
+	open map[context.Context]*batch
+}
+
+type batch struct {
+	invs    []*invocation
+	results []batchResult
+	once    sync.Once
+
+	// dispatched is closed once the batch has been handed to the backend.
+	dispatched chan struct{}
+}
+
+func newLoader(b batchBackend, withTimeout timeoutFunc, describeErr errFunc) *loader {
+	return &loader{
+		b:           b,
+		withTimeout: withTimeout,
+		describeErr: describeErr,
+		open:        make(map[context.Context]*batch),
+	}
+}
+
+// load adds inv to the batch being collected for the request ctx belongs to.
+func (l *loader) load(ctx context.Context, inv *invocation) func() (*output, error) {
+	l.Lock()
+	b, ok := l.open[ctx]
+	if !ok {
+		b = &batch{dispatched: make(chan struct{})}
+		l.open[ctx] = b
+
+		// don't hold on to batches whose results were never asked for;
+		// there's nothing to wait for if ctx can't be cancelled
+		if done := ctx.Done(); done != nil {
+			go func() {
+				select {
+				case <-done:
+					l.close(ctx, b)
+				case <-b.dispatched:
+				}
+			}()
+		}
+	}
+
+	var idx = len(b.invs)
+	b.invs = append(b.invs, inv)
+	l.Unlock()
+
+	return func() (*output, error) {
+		b.once.Do(func() {
+			close(b.dispatched)
+			l.close(ctx, b)
+			b.results = l.dispatch(ctx, b.invs)
+		})
+
+		var r = b.results[idx]
+		if r.err != nil {
+			return nil, r.err
+		}
+
+		return &output{body: r.body}, nil
+	}
+}
+
+// close stops b from collecting any more invocations.
+func (l *loader) close(ctx context.Context, b *batch) {
+	l.Lock()
+	if l.open[ctx] == b {
+		delete(l.open, ctx)
+	}
+	l.Unlock()
+}
+
+func (l *loader) dispatch(ctx context.Context, invs []*invocation) []batchResult {
+	var (
+		header  http.Header
+		results []batchResult
+	)
+
+	release, err := middleware.AcquireResolverSlot(ctx)
+	if err != nil {
+		return l.fail(ctx, invs, err)
+	}
+	defer release()
+
+	runCtx, cancel := l.withTimeout(ctx)
+	defer cancel()
+
+	header, results, err = l.b.runBatch(runCtx, invs)
+	if err == nil && len(results) != len(invs) {
+		err = fmt.Errorf(
+			"batch resolver returned %d results for %d invocations",
+			len(results), len(invs),
+		)
+	}
+
+	if err != nil {
+		return l.fail(runCtx, invs, err)
+	}
+
+	if 0 < len(header) {
+		h := middleware.GetWHeader(ctx)
+		for k, vv := range header {
+			for _, v := range vv {
+				h.Add(k, v)
+			}
+		}
+	}
+
+	// errors reported for single invocations are logged and masked like those of unbatched fields
+	for idx := range results {
+		if err := results[idx].err; err != nil {
+			results[idx].err = l.describeErr(ctx, err)
+		}
+	}
+
+	return results
+}
+
+// fail reports err as the result of every invocation in invs.
+func (l *loader) fail(ctx context.Context, invs []*invocation, err error) []batchResult {
+	err = l.describeErr(ctx, err)
+
+	var results = make([]batchResult, len(invs))
+	for idx := range results {
+		results[idx].err = err
+	}
+
+	return results
+}
+
+type batchExecBackend struct {
+	execBackend
+}
+
+// NewBatchExecBackend returns a Backend that runs the executable at path once for all sibling
+// invocations of a field; it reads a JSON list of sources and arguments from stdin and
+// writes a JSON list of results, one for each invocation in the same order.
+// Its runs are recorded to or replayed from t, if it's not nil.
+func NewBatchExecBackend(path, wd string, l config.Limits, t *Tape, c *config.GraphConf) Backend {
+	return &batchExecBackend{
+		execBackend: execBackend{
+			path:    path,
+			wd:      wd,
+			user:    c.User,
+			sandbox: c.Sandbox,
+			limits:  l,
+			tape:    t,
+		},
+	}
+}
+
+func (bb *batchExecBackend) run(ctx context.Context, inv *invocation) (*output, error) {
+	header, results, err := bb.runBatch(ctx, []*invocation{inv})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(results) != 1 {
+		return nil, fmt.Errorf("batch resolver returned %d results for 1 invocation", len(results))
+	}
+
+	if err := results[0].err; err != nil {
+		return nil, err
+	}
+
+	return &output{
+		header: header,
+		body:   results[0].body,
+	}, nil
+}
+
+func (bb *batchExecBackend) runBatch(ctx context.Context, invs []*invocation) (http.Header, []batchResult, error) {
+	type batchItem struct {
+		Source interface{}            `json:"source"`
+		Args   map[string]interface{} `json:"args"`
+		Info   *resolveInfo           `json:"info"`
+	}
+
+	var items = make([]batchItem, len(invs))
+	for idx, inv := range invs {
+		items[idx] = batchItem{
+			Source: inv.params.Source,
+			Args:   inv.params.Args,
+			Info:   inv.info(),
+		}
+	}
+
+	stdin, err := json.Marshal(items)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error encoding batch as JSON: %w", err)
+	}
+
+	cmd := newCommand(ctx, bb.path)
+	cmd.limits = bb.limits
+	cmd.sandbox = bb.sandbox
+	cmd.Stdin = bytes.NewReader(stdin)
+	cmd.tape = bb.tape
+
+	if user := bb.user; user != nil {
+		cmd.SysProcAttr.Credential = &syscall.Credential{
+			Uid: user.Uid,
+			Gid: user.Gid,
+		}
+	}
+
+	env := append([]string{}, middleware.GetEnv(ctx)...)
+	env = append(env,
+		"SCRIPT_NAME="+filepath.Base(bb.path),
+		"SCRIPT_FILENAME="+bb.path,
+		fmt.Sprintf("GRAPHQLD_BATCH_SIZE=%d", len(invs)),
+	)
+	cmd.Env = env
+
+	if ctxFile := middleware.GetCtxFile(ctx); ctxFile != nil {
+		cmd.ExtraFiles = []*os.File{ctxFile}
+	}
+
+	if bb.wd != "" {
+		cmd.Dir = bb.wd
+	}
+
+	data, err := cmd.output()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	out, err := splitOutput(data)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var responses []struct {
+		Data  json.RawMessage `json:"data"`
+		Error json.RawMessage `json:"error"`
+	}
+	if err := json.Unmarshal(out.body, &responses); err != nil {
+		return nil, nil, fmt.Errorf("unable to parse batch resolver output: %w", err)
+	}
+
+	var results = make([]batchResult, len(responses))
+	for idx, resp := range responses {
+		if errs := errorsFromJSON(resp.Error); 0 < len(errs) {
+			results[idx].err = errs
+			continue
+		}
+
+		results[idx].body = dataBody(resp.Data)
+	}
+
+	return out.header, results, nil
+}
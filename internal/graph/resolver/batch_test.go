@@ -0,0 +1,259 @@
+package resolver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/graphql-go/graphql"
+	"github.com/raphaelreyna/graphqld/internal/config"
+)
+
+// fakeBatchBackend resolves each invocation with the name of its field, or fails those named "fail".
+type fakeBatchBackend struct {
+	sync.Mutex
+	batches [][]string
+
+	// results, if set, overrides how many results are returned.
+	results int
+}
+
+func (fb *fakeBatchBackend) String() string {
+	return "fake"
+}
+
+func (fb *fakeBatchBackend) run(ctx context.Context, inv *invocation) (*output, error) {
+	return nil, errors.New("not batched")
+}
+
+func (fb *fakeBatchBackend) runBatch(ctx context.Context, invs []*invocation) (http.Header, []batchResult, error) {
+	var names = make([]string, len(invs))
+	for idx, inv := range invs {
+		names[idx] = inv.fieldName
+	}
+
+	fb.Lock()
+	fb.batches = append(fb.batches, names)
+	fb.Unlock()
+
+	var results = make([]batchResult, len(invs))
+	for idx, inv := range invs {
+		if inv.fieldName == "fail" {
+			results[idx].err = reportedErrors{{message: "failed " + inv.fieldName}}
+			continue
+		}
+
+		results[idx].body = []byte(inv.fieldName)
+	}
+
+	if fb.results != 0 {
+		results = results[:fb.results]
+	}
+
+	return nil, results, nil
+}
+
+func newTestLoader(b batchBackend) *loader {
+	return newLoader(b,
+		func(ctx context.Context) (context.Context, context.CancelFunc) {
+			return context.WithCancel(ctx)
+		},
+		func(ctx context.Context, err error) error {
+			return fmt.Errorf("described: %w", err)
+		},
+	)
+}
+
+func TestLoaderMapsResults(t *testing.T) {
+	var (
+		fb = fakeBatchBackend{}
+		l  = newTestLoader(&fb)
+
+		ctx, cancel = context.WithCancel(context.Background())
+		names       = []string{"a", "fail", "c"}
+		thunks      = make([]func() (*output, error), len(names))
+	)
+	defer cancel()
+
+	for idx, name := range names {
+		thunks[idx] = l.load(ctx, &invocation{fieldName: name})
+	}
+
+	// results are asked for in any order
+	for idx := len(names) - 1; 0 <= idx; idx-- {
+		out, err := thunks[idx]()
+
+		if names[idx] == "fail" {
+			var errs reportedErrors
+			if !errors.As(err, &errs) || err.Error() != "described: failed fail" {
+				t.Errorf("expected the described error reported for %s, got %v", names[idx], err)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Fatalf("unexpected error for %s: %v", names[idx], err)
+		}
+
+		if string(out.body) != names[idx] {
+			t.Errorf("expected %q for invocation %d, got %q", names[idx], idx, out.body)
+		}
+	}
+
+	if want := [][]string{names}; !reflect.DeepEqual(fb.batches, want) {
+		t.Fatalf("expected batches %v, got %v", want, fb.batches)
+	}
+
+	// invocations made once a batch was dispatched go in a new one
+	if out, err := l.load(ctx, &invocation{fieldName: "d"})(); err != nil || string(out.body) != "d" {
+		t.Fatalf("expected d, got %v, %v", out, err)
+	}
+
+	if want := [][]string{names, {"d"}}; !reflect.DeepEqual(fb.batches, want) {
+		t.Fatalf("expected batches %v, got %v", want, fb.batches)
+	}
+}
+
+func TestLoaderSeparatesRequests(t *testing.T) {
+	var (
+		fb = fakeBatchBackend{}
+		l  = newTestLoader(&fb)
+
+		ctxA, cancelA = context.WithCancel(context.Background())
+		ctxB, cancelB = context.WithCancel(context.Background())
+	)
+	defer cancelA()
+	defer cancelB()
+
+	var (
+		a = l.load(ctxA, &invocation{fieldName: "a"})
+		b = l.load(ctxB, &invocation{fieldName: "b"})
+	)
+
+	for _, thunk := range []func() (*output, error){a, b} {
+		if _, err := thunk(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if len(fb.batches) != 2 {
+		t.Fatalf("expected a batch per request, got %v", fb.batches)
+	}
+}
+
+func TestLoaderReleasesBatches(t *testing.T) {
+	var (
+		fb = fakeBatchBackend{}
+		l  = newTestLoader(&fb)
+
+		ctx, cancel = context.WithCancel(context.Background())
+		before      = runtime.NumGoroutine()
+	)
+	defer cancel()
+
+	for idx := 0; idx < 100; idx++ {
+		if _, err := l.load(ctx, &invocation{fieldName: "a"})(); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := l.load(context.Background(), &invocation{fieldName: "b"})(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// batches are forgotten once dispatched, even though their contexts are still live
+	l.Lock()
+	var open = len(l.open)
+	l.Unlock()
+	if open != 0 {
+		t.Errorf("expected no open batches, got %d", open)
+	}
+
+	var deadline = time.Now().Add(time.Second)
+	for before < runtime.NumGoroutine() {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected %d goroutines once every batch was dispatched, got %d", before, runtime.NumGoroutine())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestLoaderResultCountMismatch(t *testing.T) {
+	var (
+		fb = fakeBatchBackend{results: 1}
+		l  = newTestLoader(&fb)
+
+		ctx, cancel = context.WithCancel(context.Background())
+	)
+	defer cancel()
+
+	var (
+		a = l.load(ctx, &invocation{fieldName: "a"})
+		b = l.load(ctx, &invocation{fieldName: "b"})
+	)
+
+	for _, thunk := range []func() (*output, error){a, b} {
+		_, err := thunk()
+		if err == nil || err.Error() != "described: batch resolver returned 1 results for 2 invocations" {
+			t.Fatalf("expected every invocation to fail, got %v", err)
+		}
+	}
+}
+
+func TestBatchExecBackend(t *testing.T) {
+	var (
+		dir    = t.TempDir()
+		script = filepath.Join(dir, "batch")
+	)
+
+	// the script checks it got every invocation before answering for them
+	var err = ioutil.WriteFile(script, []byte(`#!/bin/sh
+[ "$GRAPHQLD_BATCH_SIZE" = 4 ] || { echo "batch of $GRAPHQLD_BATCH_SIZE" >&2; exit 1; }
+printf 'X-Batch: yes\r\n\r\n'
+echo '[{"data": "a"}, {"data": {"x": 1}}, {"error": "boom"}, {"data": null, "error": {"message": "m", "extensions": {"code": "C"}}}]'
+`), 0700)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var (
+		b    = NewBatchExecBackend(script, dir, config.Limits{}, nil, &config.GraphConf{}).(*batchExecBackend)
+		invs = make([]*invocation, 4)
+	)
+	for idx := range invs {
+		invs[idx] = &invocation{
+			objName:   "User",
+			fieldName: "friend",
+			params: graphql.ResolveParams{
+				Source: map[string]interface{}{"id": idx},
+			},
+		}
+	}
+
+	header, results, err := b.runBatch(context.Background(), invs)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := header.Get("X-Batch"); got != "yes" {
+		t.Errorf("expected the X-Batch header, got %q", got)
+	}
+
+	var want = []batchResult{
+		{body: []byte("a")},
+		{body: []byte(`{"x": 1}`)},
+		{err: reportedErrors{{message: "boom"}}},
+		{err: reportedErrors{{message: "m", extensions: map[string]interface{}{"code": "C"}}}},
+	}
+	if !reflect.DeepEqual(results, want) {
+		t.Fatalf("expected %#v, got %#v", want, results)
+	}
+}
@@ -0,0 +1,186 @@
+package resolver
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"syscall"
+	"time"
+
+	"github.com/raphaelreyna/graphqld/internal/config"
+	"github.com/raphaelreyna/graphqld/internal/limits"
+	"github.com/raphaelreyna/graphqld/internal/middleware"
+	"github.com/raphaelreyna/graphqld/internal/sandbox"
+)
+
+// killGracePeriod is how long a resolver's process group has to exit
+// after receiving SIGTERM before it is sent SIGKILL.
+const killGracePeriod = 2 * time.Second
+
+// command is a resolver process that runs in its own process group.
+// The whole group is terminated once ctx is done.
+type command struct {
+	*exec.Cmd
+
+	ctx    context.Context
+	kill   context.CancelFunc
+	exited chan struct{}
+
+	// limits are set on the process before its executable runs.
+	limits config.Limits
+
+	// sandbox is the profile the process is run with, if any.
+	sandbox *sandbox.Profile
+
+	// argv is what the command was given before being wrapped in a sandbox or the limits helper.
+	argv []string
+
+	// tape records the command, or replays it instead of running it, if set.
+	tape *Tape
+
+	// errFile is the file the command may write errors to, if it has one.
+	errFile *os.File
+}
+
+func newCommand(ctx context.Context, path string, args ...string) *command {
+	// the exec.Cmd is bound to killCtx rather than ctx so that the process
+	// group gets a chance to handle SIGTERM before anything is killed.
+	killCtx, kill := context.WithCancel(context.Background())
+
+	cmd := exec.CommandContext(killCtx, path, args...)
+	cmd.SysProcAttr = &syscall.SysProcAttr{
+		Setpgid: true,
+	}
+
+	return &command{
+		Cmd:    cmd,
+		ctx:    ctx,
+		kill:   kill,
+		exited: make(chan struct{}),
+	}
+}
+
+func (c *command) start() error {
+	c.argv = c.Args
+	if c.sandbox != nil {
+		if err := sandbox.Wrap(c.Cmd, c.sandbox); err != nil {
+			c.kill()
+			return err
+		}
+	}
+
+	if err := limits.Wrap(c.Cmd, c.limits); err != nil {
+		c.kill()
+		return err
+	}
+
+	if err := c.Start(); err != nil {
+		c.kill()
+		return err
+	}
+
+	go c.terminateOnDone()
+
+	return nil
+}
+
+func (c *command) wait() error {
+	defer c.kill()
+
+	err := c.Wait()
+	close(c.exited)
+
+	return err
+}
+
+// output runs the command and returns its standard output.
+// If the command exits with a non-zero status, an *exitError holding
+// the errors it wrote to stderr is returned.
+// The process group is killed if either stdout or stderr exceeds the output limit.
+func (c *command) output() ([]byte, error) {
+	var call *Call
+	if c.tape != nil {
+		var err error
+		if call, err = c.newCall(); err != nil {
+			return nil, err
+		}
+
+		if c.tape.replaying() {
+			return c.replayRun(call)
+		}
+	}
+
+	var (
+		stdout = limits.Buffer{Max: c.limits.Output, OnExceed: c.killGroup}
+		stderr = limits.Buffer{Max: c.limits.Output, OnExceed: c.killGroup}
+	)
+	c.Stdout = &stdout
+	c.Stderr = &stderr
+
+	if err := c.start(); err != nil {
+		return nil, err
+	}
+
+	err := c.wait()
+	if call != nil {
+		if err := c.recordRun(call, stdout.Bytes(), stderr.Bytes()); err != nil {
+			middleware.GetLogger(c.ctx).Warn().Err(err).
+				Str("resolver", c.argv[0]).
+				Msg("unable to record resolver")
+		}
+	}
+
+	if stdout.Exceeded() || stderr.Exceeded() {
+		return nil, fmt.Errorf(
+			"resolver wrote more than %d bytes: %w",
+			c.limits.Output, limits.ErrOutputTooLarge,
+		)
+	}
+
+	return stdout.Bytes(), c.exitErr(err, stderr.Bytes())
+}
+
+// exitErr turns an *exec.ExitError returned by Wait into an *exitError
+// holding the errors the command wrote to stderr.
+func (c *command) exitErr(err error, stderr []byte) error {
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		return &exitError{
+			status: exitErr.ExitCode(),
+			errs:   parseErrors(stderr),
+			argv:   c.argv,
+			stderr: stderr,
+		}
+	}
+
+	return err
+}
+
+// killGroup kills the commands whole process group right away.
+func (c *command) killGroup() {
+	syscall.Kill(-c.Process.Pid, syscall.SIGKILL)
+}
+
+func (c *command) terminateOnDone() {
+	select {
+	case <-c.exited:
+		return
+	case <-c.ctx.Done():
+	}
+
+	var pgid = c.Process.Pid
+
+	syscall.Kill(-pgid, syscall.SIGTERM)
+
+	var timer = time.NewTimer(killGracePeriod)
+	defer timer.Stop()
+
+	select {
+	case <-c.exited:
+	case <-timer.C:
+	}
+
+	// anything left in the group ignored SIGTERM
+	syscall.Kill(-pgid, syscall.SIGKILL)
+	c.kill()
+}
@@ -0,0 +1,308 @@
+package resolver
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/graphql-go/graphql/gqlerrors"
+)
+
+// maxErrorsSize is how much of a resolvers error file is read.
+const maxErrorsSize = 1 << 20 // 1MB
+
+// resolverError is a single error reported by a resolver.
+type resolverError struct {
+	message string
+
+	// path is relative to the field being resolved.
+	path []interface{}
+
+	extensions map[string]interface{}
+
+	// safe errors are shown to clients even when errors are masked.
+	safe bool
+}
+
+func (re *resolverError) Error() string {
+	return re.message
+}
+
+func (re *resolverError) Extensions() map[string]interface{} {
+	return re.extensions
+}
+
+// outputError is a resolver output that couldn't be coerced to the type of its field;
+// it may quote what the resolver wrote.
+type outputError struct {
+	err error
+}
+
+func (oe *outputError) Error() string {
+	return oe.err.Error()
+}
+
+func (oe *outputError) Unwrap() error {
+	return oe.err
+}
+
+// reportedErrors are the errors a resolver reported while resolving a field.
+type reportedErrors []*resolverError
+
+func (re reportedErrors) Error() string {
+	var msgs = make([]string, len(re))
+	for idx, e := range re {
+		msgs[idx] = e.message
+	}
+
+	return strings.Join(msgs, "; ")
+}
+
+// withCode returns a copy of re where every error without an extensions code has the given code.
+func (re reportedErrors) withCode(code string) reportedErrors {
+	if code == "" {
+		return re
+	}
+
+	var ret = make(reportedErrors, len(re))
+	for idx, e := range re {
+		if _, ok := e.extensions["code"]; ok {
+			ret[idx] = e
+			continue
+		}
+
+		var ext = map[string]interface{}{"code": code}
+		for k, v := range e.extensions {
+			ext[k] = v
+		}
+
+		ret[idx] = &resolverError{
+			message:    e.message,
+			path:       e.path,
+			extensions: ext,
+			safe:       e.safe,
+		}
+	}
+
+	return ret
+}
+
+// mask returns a copy of re where every error not marked as safe
+// is replaced by a generic one referencing id.
+func (re reportedErrors) mask(id string) reportedErrors {
+	var ret = make(reportedErrors, len(re))
+	for idx, e := range re {
+		if e.safe {
+			ret[idx] = e
+			continue
+		}
+
+		ret[idx] = &resolverError{
+			message: fmt.Sprintf("internal error (id: %s)", id),
+			path:    e.path,
+			extensions: map[string]interface{}{
+				"code":    "INTERNAL_SERVER_ERROR",
+				"errorId": id,
+			},
+		}
+	}
+
+	return ret
+}
+
+// newErrorID returns a random id used to match masked errors with the log entries describing them.
+func newErrorID() string {
+	var b [8]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "unknown"
+	}
+
+	return hex.EncodeToString(b[:])
+}
+
+// errorJSON is how a resolver describes an error as JSON.
+type errorJSON struct {
+	Message    string                 `json:"message"`
+	Path       []interface{}          `json:"path"`
+	Extensions map[string]interface{} `json:"extensions"`
+	Safe       bool                   `json:"safe"`
+}
+
+// parseErrors reads the errors a resolver reported in data.
+// data may be a sequence of JSON error objects or lists of error objects;
+// anything else is taken as the message of a single error.
+func parseErrors(data []byte) reportedErrors {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 {
+		return nil
+	}
+
+	var (
+		errs reportedErrors
+		dec  = json.NewDecoder(bytes.NewReader(data))
+	)
+	for {
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			if errors.Is(err, io.EOF) {
+				return errs
+			}
+
+			break
+		}
+
+		var objs []errorJSON
+		switch raw[0] {
+		case '[':
+			if err := json.Unmarshal(raw, &objs); err != nil {
+				return reportedErrors{{message: string(data)}}
+			}
+		case '{':
+			var obj errorJSON
+			if err := json.Unmarshal(raw, &obj); err != nil {
+				return reportedErrors{{message: string(data)}}
+			}
+			objs = append(objs, obj)
+		default:
+			return reportedErrors{{message: string(data)}}
+		}
+
+		for _, obj := range objs {
+			if obj.Message == "" {
+				return reportedErrors{{message: string(data)}}
+			}
+
+			errs = append(errs, &resolverError{
+				message:    obj.Message,
+				path:       obj.Path,
+				extensions: obj.Extensions,
+				safe:       obj.Safe,
+			})
+		}
+	}
+
+	return reportedErrors{{message: string(data)}}
+}
+
+// errorsFromJSON reads the errors a resolver reported in a JSON value;
+// strings are parsed the same way as stderr would be.
+func errorsFromJSON(data json.RawMessage) reportedErrors {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		return parseErrors([]byte(s))
+	}
+
+	if len(data) == 0 || string(data) == "null" {
+		return nil
+	}
+
+	return parseErrors(data)
+}
+
+// exitError is returned when a resolver process exits with a non-zero status.
+type exitError struct {
+	status int
+	errs   reportedErrors
+
+	// argv and stderr are only ever logged.
+	argv   []string
+	stderr []byte
+}
+
+func (ee *exitError) Error() string {
+	if len(ee.errs) == 0 {
+		return fmt.Sprintf("resolver exited with status %d", ee.status)
+	}
+
+	return ee.errs.Error()
+}
+
+// reported returns the errors the resolver reported before exiting.
+func (ee *exitError) reported() reportedErrors {
+	if len(ee.errs) == 0 {
+		return reportedErrors{{message: ee.Error()}}
+	}
+
+	return ee.errs
+}
+
+// newTempFile returns an anonymous file to be handed to a resolver process.
+func newTempFile(prefix string) (*os.File, error) {
+	f, err := ioutil.TempFile("", prefix)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create temporary file: %w", err)
+	}
+
+	if err := os.Remove(f.Name()); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("unable to unlink temporary file: %w", err)
+	}
+
+	return f, nil
+}
+
+// readErrorFile returns the errors written to f.
+func readErrorFile(f *os.File) (reportedErrors, error) {
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	data, err := ioutil.ReadAll(io.LimitReader(f, maxErrorsSize))
+	if err != nil {
+		return nil, err
+	}
+
+	return parseErrors(data), nil
+}
+
+// FormatErrors expands errors reported by resolvers into the GraphQL errors they describe,
+// with their paths made relative to the response and their extensions set.
+func FormatErrors(errs []gqlerrors.FormattedError) []gqlerrors.FormattedError {
+	var ret = make([]gqlerrors.FormattedError, 0, len(errs))
+
+	for _, fe := range errs {
+		re, ok := reportedFrom(fe)
+		if !ok {
+			ret = append(ret, fe)
+			continue
+		}
+
+		for _, e := range re {
+			var path = append(append([]interface{}{}, fe.Path...), e.path...)
+
+			ret = append(ret, gqlerrors.FormattedError{
+				Message:    e.message,
+				Locations:  fe.Locations,
+				Path:       path,
+				Extensions: e.extensions,
+			})
+		}
+	}
+
+	return ret
+}
+
+// reportedFrom digs through the errors wrapped by err looking for errors reported by a resolver.
+func reportedFrom(err error) (reportedErrors, bool) {
+	for err != nil {
+		switch e := err.(type) {
+		case reportedErrors:
+			return e, true
+		case gqlerrors.FormattedError:
+			err = e.OriginalError()
+		case *gqlerrors.Error:
+			err = e.OriginalError
+		default:
+			err = errors.Unwrap(err)
+		}
+	}
+
+	return nil, false
+}
@@ -0,0 +1,163 @@
+package resolver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/graphql-go/graphql"
+	"github.com/raphaelreyna/graphqld/internal/config"
+)
+
+func TestParseErrors(t *testing.T) {
+	var tests = []struct {
+		name string
+		data string
+		want reportedErrors
+	}{
+		{name: "nothing", data: " \n", want: nil},
+		{name: "plain text", data: "boom\n", want: reportedErrors{{message: "boom"}}},
+		{
+			name: "object",
+			data: `{"message": "a", "path": ["x", 0], "extensions": {"code": "X"}, "safe": true}`,
+			want: reportedErrors{{
+				message:    "a",
+				path:       []interface{}{"x", 0.0},
+				extensions: map[string]interface{}{"code": "X"},
+				safe:       true,
+			}},
+		},
+		{
+			name: "list",
+			data: `[{"message": "a"}, {"message": "b"}]`,
+			want: reportedErrors{{message: "a"}, {message: "b"}},
+		},
+		{
+			name: "sequence",
+			data: "{\"message\": \"a\"}\n[{\"message\": \"b\"}]\n",
+			want: reportedErrors{{message: "a"}, {message: "b"}},
+		},
+		{
+			name: "object without a message",
+			data: `{"code": 1}`,
+			want: reportedErrors{{message: `{"code": 1}`}},
+		},
+		{
+			name: "object followed by text",
+			data: `{"message": "a"} and more`,
+			want: reportedErrors{{message: `{"message": "a"} and more`}},
+		},
+		{name: "json that isn't an error", data: "[1, 2]", want: reportedErrors{{message: "[1, 2]"}}},
+		{name: "number", data: "42", want: reportedErrors{{message: "42"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseErrors([]byte(tt.data)); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("expected %#v, got %#v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestErrorsFromJSON(t *testing.T) {
+	var tests = []struct {
+		name string
+		data string
+		want reportedErrors
+	}{
+		{name: "missing", data: "", want: nil},
+		{name: "null", data: "null", want: nil},
+		{name: "empty string", data: `""`, want: nil},
+		{name: "string", data: `"boom"`, want: reportedErrors{{message: "boom"}}},
+		{name: "string holding an error", data: `"{\"message\": \"a\"}"`, want: reportedErrors{{message: "a"}}},
+		{
+			name: "object",
+			data: `{"message": "a", "extensions": {"code": "X"}}`,
+			want: reportedErrors{{message: "a", extensions: map[string]interface{}{"code": "X"}}},
+		},
+		{name: "list", data: `[{"message": "a"}, {"message": "b"}]`, want: reportedErrors{{message: "a"}, {message: "b"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := errorsFromJSON(json.RawMessage(tt.data)); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("expected %#v, got %#v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestExitError(t *testing.T) {
+	var ee = exitError{status: 3}
+	if got, want := ee.Error(), "resolver exited with status 3"; got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+
+	if got, want := ee.reported(), (reportedErrors{{message: "resolver exited with status 3"}}); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %#v, got %#v", want, got)
+	}
+
+	ee.errs = reportedErrors{{message: "a"}, {message: "b"}}
+	if got, want := ee.Error(), "a; b"; got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+}
+
+// staticBackend resolves every invocation with body.
+type staticBackend struct {
+	body string
+}
+
+func (sb staticBackend) run(ctx context.Context, inv *invocation) (*output, error) {
+	return &output{body: []byte(sb.body)}, nil
+}
+
+func (sb staticBackend) String() string {
+	return "static"
+}
+
+func TestInvalidOutputErrors(t *testing.T) {
+	var tests = []struct {
+		name       string
+		maskErrors bool
+	}{
+		{name: "masked", maskErrors: true},
+		{name: "unmasked", maskErrors: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var c = config.NewGraphConf(t.TempDir())
+			c.MaskErrors = tt.maskErrors
+
+			// mutation fields are resolved right away rather than through a thunk
+			var field = &graphql.FieldDefinition{Name: "n", Type: graphql.Int}
+			resolve, err := NewFieldResolveFn("Mutation", field, staticBackend{body: "secret token"}, &c)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			_, err = (*resolve)(graphql.ResolveParams{
+				Context: requestContext(t, nil, nil, nil),
+				Info:    graphql.ResolveInfo{Path: &graphql.ResponsePath{Key: "n"}},
+			})
+
+			var errs reportedErrors
+			if !errors.As(err, &errs) || len(errs) != 1 {
+				t.Fatalf("expected a reported error, got %#v", err)
+			}
+
+			if leaked := strings.Contains(err.Error(), "secret token"); leaked == tt.maskErrors {
+				t.Fatalf("expected the output to be quoted only when errors aren't masked, got %q", err)
+			}
+
+			if tt.maskErrors && errs[0].extensions["code"] != "INTERNAL_SERVER_ERROR" {
+				t.Errorf("expected an internal error, got %#v", errs[0])
+			}
+		})
+	}
+}
@@ -0,0 +1,143 @@
+package resolver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/raphaelreyna/graphqld/internal/config"
+	"github.com/raphaelreyna/graphqld/internal/fastcgi"
+	"github.com/raphaelreyna/graphqld/internal/middleware"
+)
+
+type fastCGIBackend struct {
+	client *fastcgi.Client
+	script string
+}
+
+// NewFastCGIBackend returns a Backend that resolves fields by sending a request to a FastCGI application,
+// reading no more than l.Output bytes of its stdout and stderr.
+func NewFastCGIBackend(fc config.FastCGIConf, l config.Limits) Backend {
+	var client = fastcgi.NewClient(fc.Address)
+	client.MaxOutput = l.Output
+
+	return &fastCGIBackend{
+		client: client,
+		script: fc.Script,
+	}
+}
+
+func (fb *fastCGIBackend) String() string {
+	return fb.client.String() + " " + fb.script
+}
+
+func (fb *fastCGIBackend) run(ctx context.Context, inv *invocation) (*output, error) {
+	var (
+		params = make(map[string]string)
+		query  = make(url.Values)
+	)
+
+	for _, kv := range middleware.GetEnv(ctx) {
+		if idx := strings.IndexByte(kv, '='); idx != -1 {
+			params[kv[:idx]] = kv[idx+1:]
+		}
+	}
+
+	for name, arg := range inv.args {
+		query.Set(name, arg)
+	}
+
+	source, err := inv.source()
+	if err != nil {
+		return nil, err
+	}
+
+	params["SCRIPT_FILENAME"] = fb.script
+	params["SCRIPT_NAME"] = "/" + filepath.Base(fb.script)
+	params["REQUEST_METHOD"] = "POST"
+	params["QUERY_STRING"] = query.Encode()
+	params["REQUEST_URI"] = params["SCRIPT_NAME"] + "?" + params["QUERY_STRING"]
+	params["CONTENT_TYPE"] = "application/json"
+	params["CONTENT_LENGTH"] = strconv.Itoa(len(source))
+	params["GRAPHQLD_OBJECT"] = inv.objName
+	params["GRAPHQLD_FIELD"] = inv.fieldName
+
+	var info = inv.info()
+	for _, kv := range info.env() {
+		if idx := strings.IndexByte(kv, '='); idx != -1 {
+			params[kv[:idx]] = kv[idx+1:]
+		}
+	}
+
+	infoData, err := json.Marshal(info)
+	if err != nil {
+		return nil, fmt.Errorf("error encoding resolve info as JSON: %w", err)
+	}
+	params["GRAPHQLD_INFO"] = string(infoData)
+
+	// file descriptors can't be sent to a FastCGI application so the context goes in a param too
+	if ctxFile := middleware.GetCtxFile(ctx); ctxFile != nil {
+		data, err := ioutil.ReadFile(ctxFile.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		params["GRAPHQLD_CONTEXT"] = string(data)
+	}
+
+	resp, err := fb.client.Do(ctx, params, source)
+	if err != nil {
+		return nil, err
+	}
+
+	if 0 < len(resp.Stderr) {
+		middleware.GetLogger(ctx).Warn().
+			Str("object", inv.objName).
+			Str("field", inv.fieldName).
+			Str("resolver", fb.String()).
+			Str("stderr", string(resp.Stderr)).
+			Msg("fastcgi application wrote to stderr")
+	}
+
+	if resp.AppStatus != 0 {
+		var errs = parseErrors(resp.Stderr)
+		if len(errs) == 0 {
+			errs = reportedErrors{{
+				message: fmt.Sprintf("fastcgi application exited with status %d", resp.AppStatus),
+			}}
+		}
+
+		return nil, &exitError{
+			status: int(resp.AppStatus),
+			errs:   errs,
+			stderr: resp.Stderr,
+		}
+	}
+
+	out, err := splitOutput(resp.Stdout)
+	if err != nil {
+		return nil, err
+	}
+
+	if status := out.header.Get("Status"); status != "" {
+		code, _ := strconv.Atoi(strings.Fields(status)[0])
+		if 400 <= code {
+			if errs := parseErrors(out.body); 0 < len(errs) {
+				return nil, errs
+			}
+
+			return nil, reportedErrors{{message: status}}
+		}
+	}
+
+	// these describe the FastCGI response itself rather than anything for the client
+	out.header.Del("Status")
+	out.header.Del("Content-Type")
+
+	return out, nil
+}
@@ -0,0 +1,230 @@
+package resolver
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"text/template"
+
+	"github.com/raphaelreyna/graphqld/internal/config"
+	"github.com/raphaelreyna/graphqld/internal/middleware"
+)
+
+// maxHTTPResponseSize caps how much of an upstream response body is read.
+const maxHTTPResponseSize = 10 << 20 // 10 MB
+
+var httpTemplateFuncs = template.FuncMap{
+	"json": func(v interface{}) (string, error) {
+		data, err := json.Marshal(v)
+		return string(data), err
+	},
+	"pathescape": func(v interface{}) string {
+		return url.PathEscape(fmt.Sprint(v))
+	},
+	"queryescape": func(v interface{}) string {
+		return url.QueryEscape(fmt.Sprint(v))
+	},
+}
+
+type httpBackend struct {
+	path string
+	conf config.HTTPConf
+
+	url, body *template.Template
+	headers   map[string]*template.Template
+
+	client *http.Client
+}
+
+// NewHTTPBackend returns a Backend that resolves fields by calling an HTTP endpoint.
+// path is the descriptor file the endpoint was declared in.
+func NewHTTPBackend(path string, hc config.HTTPConf) (Backend, error) {
+	var (
+		hb = httpBackend{
+			path:    path,
+			conf:    hc,
+			headers: make(map[string]*template.Template),
+			client:  &http.Client{},
+		}
+		err error
+
+		parse = func(name, text string) (*template.Template, error) {
+			t, err := template.New(name).
+				Funcs(httpTemplateFuncs).
+				Option("missingkey=zero").
+				Parse(text)
+			if err != nil {
+				return nil, fmt.Errorf("error parsing %s template in %s: %w", name, path, err)
+			}
+
+			return t, nil
+		}
+	)
+
+	if hb.conf.Method == "" {
+		hb.conf.Method = http.MethodGet
+	}
+	hb.conf.Method = strings.ToUpper(hb.conf.Method)
+
+	if hb.url, err = parse("url", hc.URL); err != nil {
+		return nil, err
+	}
+
+	if hc.Body != "" {
+		if hb.body, err = parse("body", hc.Body); err != nil {
+			return nil, err
+		}
+	}
+
+	for k, v := range hc.Headers {
+		if hb.headers[k], err = parse("header "+k, v); err != nil {
+			return nil, err
+		}
+	}
+
+	return &hb, nil
+}
+
+func (hb *httpBackend) String() string {
+	return hb.path
+}
+
+// templateData is what the descriptors templates are executed against.
+func (hb *httpBackend) templateData(ctx context.Context, inv *invocation) (map[string]interface{}, error) {
+	var (
+		env  = make(map[string]string)
+		data = map[string]interface{}{
+			"object": inv.objName,
+			"field":  inv.fieldName,
+			"args":   inv.params.Args,
+			"source": inv.params.Source,
+			"env":    env,
+		}
+	)
+
+	for _, kv := range middleware.GetEnv(ctx) {
+		if idx := strings.IndexByte(kv, '='); idx != -1 {
+			env[kv[:idx]] = kv[idx+1:]
+		}
+	}
+
+	// round trip the info through JSON so templates use the same names as other resolvers
+	{
+		infoData, err := json.Marshal(inv.info())
+		if err != nil {
+			return nil, fmt.Errorf("error encoding resolve info as JSON: %w", err)
+		}
+
+		var info map[string]interface{}
+		if err := json.Unmarshal(infoData, &info); err != nil {
+			return nil, err
+		}
+		data["info"] = info
+	}
+
+	if ctxFile := middleware.GetCtxFile(ctx); ctxFile != nil {
+		ctxData, err := ioutil.ReadFile(ctxFile.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		var c interface{}
+		if 0 < len(ctxData) {
+			if err := json.Unmarshal(ctxData, &c); err != nil {
+				return nil, fmt.Errorf("unable to parse context: %w", err)
+			}
+		}
+		data["context"] = c
+	}
+
+	return data, nil
+}
+
+func (hb *httpBackend) run(ctx context.Context, inv *invocation) (*output, error) {
+	data, err := hb.templateData(ctx, inv)
+	if err != nil {
+		return nil, err
+	}
+
+	var execute = func(t *template.Template) (string, error) {
+		var buf bytes.Buffer
+		if err := t.Execute(&buf, data); err != nil {
+			return "", fmt.Errorf("error executing %s template in %s: %w", t.Name(), hb.path, err)
+		}
+
+		return buf.String(), nil
+	}
+
+	u, err := execute(hb.url)
+	if err != nil {
+		return nil, err
+	}
+
+	var body io.Reader
+	if hb.body != nil {
+		b, err := execute(hb.body)
+		if err != nil {
+			return nil, err
+		}
+		body = strings.NewReader(b)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, hb.conf.Method, u, body)
+	if err != nil {
+		return nil, err
+	}
+
+	if rh := middleware.GetRHeader(ctx); rh != nil {
+		for _, name := range hb.conf.ForwardHeaders {
+			for _, v := range rh.Values(name) {
+				req.Header.Add(name, v)
+			}
+		}
+	}
+
+	for name, t := range hb.headers {
+		v, err := execute(t)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set(name, v)
+	}
+
+	resp, err := hb.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxHTTPResponseSize))
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode < 200 || 299 < resp.StatusCode {
+		if errs := parseErrors(respBody); 0 < len(errs) {
+			return nil, errs
+		}
+
+		return nil, reportedErrors{{message: resp.Status}}
+	}
+
+	var out = output{
+		header: make(http.Header),
+		body:   respBody,
+	}
+
+	for _, name := range hb.conf.ResponseHeaders {
+		for _, v := range resp.Header.Values(name) {
+			out.header.Add(name, v)
+		}
+	}
+
+	return &out, nil
+}
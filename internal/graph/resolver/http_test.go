@@ -0,0 +1,211 @@
+package resolver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/graphql-go/graphql"
+	"github.com/raphaelreyna/graphqld/internal/config"
+	"github.com/raphaelreyna/graphqld/internal/middleware"
+)
+
+// upstream is the service the HTTP backends under test call; it echoes what it was sent.
+func upstream(t *testing.T) *httptest.Server {
+	var mux = http.NewServeMux()
+
+	mux.HandleFunc("/users/", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Upstream", "users")
+		w.Header().Set("X-Ignored", "yes")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"method": r.Method,
+			"path":   r.URL.EscapedPath(),
+			"query":  r.URL.Query().Get("q"),
+		})
+	})
+
+	mux.HandleFunc("/echo", func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"method":        r.Method,
+			"body":          string(body),
+			"authorization": r.Header.Get("Authorization"),
+			"cookie":        r.Header.Get("Cookie"),
+			"user":          r.Header.Get("X-User"),
+		})
+	})
+
+	mux.HandleFunc("/broken", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+		w.Write([]byte(`{"message": "upstream broke", "extensions": {"code": "UPSTREAM"}}`))
+	})
+
+	mux.HandleFunc("/gone", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusGone)
+	})
+
+	var srv = httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	return srv
+}
+
+// requestContext returns the context of a request to a graph made with env and header,
+// whose resolvers are given reqCtx as their context.
+func requestContext(t *testing.T, env []string, header http.Header, reqCtx []byte) context.Context {
+	var g = middleware.NewGraph(config.NewGraphConf(t.TempDir()))
+
+	ctx, release, err := g.NewContext(context.Background(), middleware.Request{
+		Env:            env,
+		Header:         header,
+		ResponseHeader: make(http.Header),
+		Context:        reqCtx,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(release)
+
+	return ctx
+}
+
+func TestHTTPBackend(t *testing.T) {
+	var srv = upstream(t)
+
+	var ctx = requestContext(t,
+		[]string{"BASE=" + srv.URL},
+		http.Header{
+			"Authorization": {"Bearer abc"},
+			"Cookie":        {"session=1"},
+		},
+		[]byte(`{"user": "ann"}`),
+	)
+
+	var tests = []struct {
+		name   string
+		conf   config.HTTPConf
+		args   map[string]interface{}
+		source interface{}
+
+		body   map[string]interface{}
+		header http.Header
+		errs   reportedErrors
+	}{
+		{
+			name: "get with escaped arguments",
+			conf: config.HTTPConf{
+				URL:             "{{.env.BASE}}/users/{{pathescape .args.id}}?q={{queryescape .args.q}}",
+				ResponseHeaders: []string{"X-Upstream"},
+			},
+			args: map[string]interface{}{"id": "a/b", "q": "x&y"},
+			body: map[string]interface{}{
+				"method": "GET",
+				"path":   "/users/a%2Fb",
+				"query":  "x&y",
+			},
+			header: http.Header{"X-Upstream": {"users"}},
+		},
+		{
+			name: "post with a body, forwarded headers and the context",
+			conf: config.HTTPConf{
+				Method:         "post",
+				URL:            "{{.env.BASE}}/echo",
+				Body:           `{{json .source}}`,
+				Headers:        map[string]string{"X-User": "{{.context.user}}"},
+				ForwardHeaders: []string{"Authorization"},
+			},
+			source: map[string]interface{}{"id": 1},
+			body: map[string]interface{}{
+				"method":        "POST",
+				"body":          `{"id":1}`,
+				"authorization": "Bearer abc",
+				"cookie":        "",
+				"user":          "ann",
+			},
+			header: http.Header{},
+		},
+		{
+			name: "errors reported by the upstream",
+			conf: config.HTTPConf{
+				URL: "{{.env.BASE}}/broken",
+			},
+			errs: reportedErrors{{
+				message:    "upstream broke",
+				extensions: map[string]interface{}{"code": "UPSTREAM"},
+			}},
+		},
+		{
+			name: "error status with a plain body",
+			conf: config.HTTPConf{
+				URL: "{{.env.BASE}}/missing",
+			},
+			errs: reportedErrors{{message: "404 page not found"}},
+		},
+		{
+			name: "error status without a body",
+			conf: config.HTTPConf{
+				URL: "{{.env.BASE}}/gone",
+			},
+			errs: reportedErrors{{message: "410 Gone"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, err := NewHTTPBackend("test.http.yaml", tt.conf)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			out, err := b.run(ctx, &invocation{
+				objName:   "Query",
+				fieldName: "user",
+				params: graphql.ResolveParams{
+					Args:   tt.args,
+					Source: tt.source,
+				},
+			})
+
+			if tt.errs != nil {
+				var errs reportedErrors
+				if !errors.As(err, &errs) {
+					t.Fatalf("expected reported errors, got %v", err)
+				}
+
+				if !reflect.DeepEqual(errs, tt.errs) {
+					t.Fatalf("expected errors %v, got %v", tt.errs, errs)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var body map[string]interface{}
+			if err := json.Unmarshal(out.body, &body); err != nil {
+				t.Fatalf("invalid body %q: %v", out.body, err)
+			}
+
+			if !reflect.DeepEqual(body, tt.body) {
+				t.Errorf("expected the upstream to get %v, got %v", tt.body, body)
+			}
+
+			if !reflect.DeepEqual(out.header, tt.header) {
+				t.Errorf("expected headers %v, got %v", tt.header, out.header)
+			}
+		})
+	}
+}
+
+func TestHTTPBackendInvalidTemplate(t *testing.T) {
+	if _, err := NewHTTPBackend("test.http.yaml", config.HTTPConf{URL: "{{.env.BASE"}); err == nil {
+		t.Fatal("expected an error for an unterminated template")
+	}
+}
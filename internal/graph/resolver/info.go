@@ -0,0 +1,299 @@
+package resolver
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/ast"
+)
+
+// resolveInfo is the part of graphql.ResolveInfo resolvers are given, encoded as JSON.
+type resolveInfo struct {
+	FieldName  string                 `json:"fieldName"`
+	Path       []interface{}          `json:"path"`
+	ParentType string                 `json:"parentType"`
+	ReturnType string                 `json:"returnType"`
+	Operation  operationInfo          `json:"operation"`
+	Variables  map[string]interface{} `json:"variables"`
+	Selections []*selection           `json:"selections"`
+}
+
+type operationInfo struct {
+	Name string `json:"name,omitempty"`
+	Type string `json:"type"`
+}
+
+// selection is a field selected below the field being resolved.
+type selection struct {
+	Name  string                 `json:"name"`
+	Alias string                 `json:"alias,omitempty"`
+	Args  map[string]interface{} `json:"args,omitempty"`
+
+	// On is the type condition of the fragment the field was selected in, if any.
+	On string `json:"on,omitempty"`
+
+	Selections []*selection `json:"selections,omitempty"`
+}
+
+// newResolveInfo collects what a resolver is told about the field it is resolving.
+func newResolveInfo(info graphql.ResolveInfo) *resolveInfo {
+	var ri = resolveInfo{
+		FieldName: info.FieldName,
+		Variables: info.VariableValues,
+	}
+
+	if info.Path != nil {
+		ri.Path = info.Path.AsArray()
+	}
+
+	if info.ParentType != nil {
+		ri.ParentType = info.ParentType.Name()
+	}
+
+	if info.ReturnType != nil {
+		ri.ReturnType = info.ReturnType.String()
+	}
+
+	if op, ok := info.Operation.(*ast.OperationDefinition); ok {
+		ri.Operation.Type = op.Operation
+		if op.Name != nil {
+			ri.Operation.Name = op.Name.Value
+		}
+	}
+
+	var sc = selectionCollector{
+		schema:    info.Schema,
+		fragments: info.Fragments,
+		variables: info.VariableValues,
+	}
+	for _, field := range info.FieldASTs {
+		ri.Selections = sc.collect(ri.Selections, field.SelectionSet, namedType(info.ReturnType), "")
+	}
+
+	return &ri
+}
+
+// env returns the info as environment variables.
+func (ri *resolveInfo) env() []string {
+	var path = make([]string, len(ri.Path))
+	for idx, p := range ri.Path {
+		path[idx] = fmt.Sprint(p)
+	}
+
+	return []string{
+		"GRAPHQLD_PATH=" + strings.Join(path, "."),
+		"GRAPHQLD_PARENT_TYPE=" + ri.ParentType,
+		"GRAPHQLD_RETURN_TYPE=" + ri.ReturnType,
+		"GRAPHQLD_OPERATION_NAME=" + ri.Operation.Name,
+		"GRAPHQLD_OPERATION_TYPE=" + ri.Operation.Type,
+	}
+}
+
+// newInfoFile returns an anonymous file holding the info as JSON, ready to be read from the start.
+func (ri *resolveInfo) newInfoFile() (*os.File, error) {
+	data, err := json.Marshal(ri)
+	if err != nil {
+		return nil, fmt.Errorf("error encoding resolve info as JSON: %w", err)
+	}
+
+	f, err := newTempFile("graphqld-info-")
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := f.Write(data); err == nil {
+		_, err = f.Seek(0, io.SeekStart)
+	}
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("unable to write resolve info file: %w", err)
+	}
+
+	return f, nil
+}
+
+// selectionCollector flattens selection sets into the fields they select,
+// expanding fragments and dropping skipped fields.
+type selectionCollector struct {
+	schema    graphql.Schema
+	fragments map[string]ast.Definition
+	variables map[string]interface{}
+}
+
+// collect adds the fields selected by set on an object of type typeName to sels.
+// on is the type condition of the fragment set belongs to, if it differs from typeName.
+func (sc *selectionCollector) collect(sels []*selection, set *ast.SelectionSet, typeName, on string) []*selection {
+	if set == nil {
+		return sels
+	}
+
+	for _, s := range set.Selections {
+		switch s := s.(type) {
+		case *ast.Field:
+			if !sc.included(s.Directives) {
+				continue
+			}
+
+			var sel = selection{
+				Name: s.Name.Value,
+				On:   on,
+			}
+
+			if s.Alias != nil && s.Alias.Value != sel.Name {
+				sel.Alias = s.Alias.Value
+			}
+
+			for _, arg := range s.Arguments {
+				if sel.Args == nil {
+					sel.Args = make(map[string]interface{})
+				}
+				sel.Args[arg.Name.Value] = sc.value(arg.Value)
+			}
+
+			var fieldType = typeName
+			if on != "" {
+				fieldType = on
+			}
+			fieldType = sc.fieldType(fieldType, sel.Name)
+
+			// fields selected more than once have their selections merged
+			var merged bool
+			for _, prev := range sels {
+				if prev.Name == sel.Name && prev.Alias == sel.Alias && prev.On == sel.On {
+					prev.Selections = sc.collect(prev.Selections, s.SelectionSet, fieldType, "")
+					merged = true
+					break
+				}
+			}
+
+			if !merged {
+				sel.Selections = sc.collect(nil, s.SelectionSet, fieldType, "")
+				sels = append(sels, &sel)
+			}
+		case *ast.InlineFragment:
+			if !sc.included(s.Directives) {
+				continue
+			}
+
+			var typeCond = on
+			if tc := s.TypeCondition; tc != nil && tc.Name.Value != typeName {
+				typeCond = tc.Name.Value
+			}
+
+			sels = sc.collect(sels, s.SelectionSet, typeName, typeCond)
+		case *ast.FragmentSpread:
+			if !sc.included(s.Directives) {
+				continue
+			}
+
+			frag, ok := sc.fragments[s.Name.Value].(*ast.FragmentDefinition)
+			if !ok {
+				continue
+			}
+
+			var typeCond = on
+			if tc := frag.TypeCondition; tc != nil && tc.Name.Value != typeName {
+				typeCond = tc.Name.Value
+			}
+
+			sels = sc.collect(sels, frag.SelectionSet, typeName, typeCond)
+		}
+	}
+
+	return sels
+}
+
+// fieldType returns the name of the named type of the field fieldName on the type typeName.
+func (sc *selectionCollector) fieldType(typeName, fieldName string) string {
+	var fields graphql.FieldDefinitionMap
+	switch t := sc.schema.Type(typeName).(type) {
+	case *graphql.Object:
+		fields = t.Fields()
+	case *graphql.Interface:
+		fields = t.Fields()
+	default:
+		return ""
+	}
+
+	if field, ok := fields[fieldName]; ok {
+		return namedType(field.Type)
+	}
+
+	return ""
+}
+
+func namedType(t graphql.Type) string {
+	if named := graphql.GetNamed(t); named != nil {
+		return named.String()
+	}
+
+	return ""
+}
+
+// included reports whether the @skip and @include directives let a selection through.
+func (sc *selectionCollector) included(directives []*ast.Directive) bool {
+	for _, d := range directives {
+		var cond bool
+		for _, arg := range d.Arguments {
+			if arg.Name.Value == "if" {
+				cond, _ = sc.value(arg.Value).(bool)
+			}
+		}
+
+		switch d.Name.Value {
+		case "skip":
+			if cond {
+				return false
+			}
+		case "include":
+			if !cond {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// value returns the Go value of an argument as it would be encoded as JSON.
+func (sc *selectionCollector) value(v ast.Value) interface{} {
+	switch v := v.(type) {
+	case *ast.Variable:
+		return sc.variables[v.Name.Value]
+	case *ast.IntValue:
+		if i, err := strconv.ParseInt(v.Value, 10, 64); err == nil {
+			return i
+		}
+		return v.Value
+	case *ast.FloatValue:
+		if f, err := strconv.ParseFloat(v.Value, 64); err == nil {
+			return f
+		}
+		return v.Value
+	case *ast.StringValue:
+		return v.Value
+	case *ast.BooleanValue:
+		return v.Value
+	case *ast.EnumValue:
+		return v.Value
+	case *ast.ListValue:
+		var list = make([]interface{}, len(v.Values))
+		for idx, item := range v.Values {
+			list[idx] = sc.value(item)
+		}
+		return list
+	case *ast.ObjectValue:
+		var obj = make(map[string]interface{}, len(v.Fields))
+		for _, field := range v.Fields {
+			obj[field.Name.Value] = sc.value(field.Value)
+		}
+		return obj
+	default:
+		return nil
+	}
+}
@@ -1,195 +1,327 @@
 package resolver
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/graphql-go/graphql"
+	"github.com/raphaelreyna/graphqld/internal/scalar"
 )
 
+// maxDescribedLen caps how much of an invalid value is quoted in an error.
+const maxDescribedLen = 64
+
 type outputParser func(data []byte) (interface{}, error)
 
-func newOutputParser(outputType graphql.Output) (outputParser, error) {
-	switch x := outputType.(type) {
+// newOutputParser returns the parser for the output of the resolver of the field fieldName.
+// Scalars and enums may be output as plain text, as may lists of them with one value per line;
+// anything else is output as JSON and is coerced by type all the way down.
+func newOutputParser(fieldName string, outputType graphql.Output) (outputParser, error) {
+	if err := checkOutputType(outputType); err != nil {
+		return nil, err
+	}
+
+	var where = "field " + fieldName
+
+	return func(data []byte) (interface{}, error) {
+		return parseText(outputType, data, where)
+	}, nil
+}
+
+// checkOutputType returns an error if t can't be parsed from a resolvers output.
+func checkOutputType(t graphql.Type) error {
+	switch x := t.(type) {
 	case *graphql.NonNull:
-		switch x := x.OfType.(type) {
-		case *graphql.Scalar:
-			switch x {
-			case graphql.ID:
-				fallthrough
-			case graphql.String:
-				return func(data []byte) (interface{}, error) {
-					return string(data), nil
-				}, nil
-			case graphql.Int:
-				return func(data []byte) (interface{}, error) {
-					x, err := strconv.Atoi(string(data))
-					if err != nil {
-						return nil, err
-					}
-					return x, nil
-				}, nil
-			case graphql.Float:
-				return func(data []byte) (interface{}, error) {
-					x, err := strconv.ParseFloat(string(data), 64)
-					if err != nil {
-						return nil, err
-					}
-					return x, nil
-				}, nil
-			case graphql.Boolean:
-				return func(data []byte) (interface{}, error) {
-					switch string(data) {
-					case "True":
-						fallthrough
-					case "true":
-						return true, nil
-					case "False":
-						fallthrough
-					case "false":
-						return false, nil
-					default:
-						return nil, fmt.Errorf(
-							"non bool output: %s",
-							string(data),
-						)
-					}
-				}, nil
-			case graphql.DateTime:
-				return func(data []byte) (interface{}, error) {
-					t, err := time.Parse(time.RFC3339, string(data))
-					if err != nil {
-						return nil, err
-					}
-					return t, nil
-				}, nil
-			default:
-				return nil, fmt.Errorf("unsupported return type: %T", x)
-			}
-		case *graphql.Object:
-			return func(data []byte) (interface{}, error) {
-				var jsonOutput interface{}
-				if err := json.Unmarshal(data, &jsonOutput); err != nil {
-					return nil, err
-				}
-				return jsonOutput, nil
-			}, nil
-		default:
-			return nil, fmt.Errorf("invalid output type: %T %+v", outputType, outputType)
+		return checkOutputType(x.OfType)
+	case *graphql.List:
+		return checkOutputType(x.OfType)
+	case *graphql.Scalar, *graphql.Enum, *graphql.Object, *graphql.Interface, *graphql.Union:
+		return nil
+	}
+
+	return fmt.Errorf("unsupported return type: %T", t)
+}
+
+// parseText parses the output data of a resolver for a value of type t; where describes the value in errors.
+func parseText(t graphql.Type, data []byte, where string) (interface{}, error) {
+	var nonNull bool
+	if nn, ok := t.(*graphql.NonNull); ok {
+		t, nonNull = nn.OfType, true
+	}
+
+	// strings are taken verbatim
+	if t == graphql.String || t == graphql.ID {
+		if len(data) == 0 && !nonNull {
+			return nil, nil
 		}
+
+		return string(data), nil
+	}
+
+	if data = bytes.TrimSpace(data); len(data) == 0 {
+		if nonNull {
+			return nil, fmt.Errorf("%s: expected %s!, got nothing", where, t)
+		}
+
+		return nil, nil
+	}
+
+	switch x := t.(type) {
 	case *graphql.List:
-		switch x.OfType.(type) {
-		case *graphql.Scalar:
-			return func(data []byte) (interface{}, error) {
-				var jsonOutput interface{}
-				if err := json.Unmarshal(data, &jsonOutput); err != nil {
-					return nil, err
-				}
-				return jsonOutput, nil
-			}, nil
-		case *graphql.Object:
-			return func(data []byte) (interface{}, error) {
-				var jsonOutput interface{}
-				if err := json.Unmarshal(data, &jsonOutput); err != nil {
-					return nil, err
-				}
-				return jsonOutput, nil
-			}, nil
-		default:
-			return nil, fmt.Errorf("invalid output type: %T %+v", outputType, outputType)
+		if data[0] == '[' && json.Valid(data) {
+			return parseJSON(t, data, where)
 		}
-	case *graphql.Scalar:
-		switch x {
-		case graphql.ID:
-			fallthrough
-		case graphql.String:
-			return func(data []byte) (interface{}, error) {
-				if len(data) == 0 {
-					return nil, nil
-				}
 
-				return string(data), nil
-			}, nil
-		case graphql.Int:
-			return func(data []byte) (interface{}, error) {
-				if len(data) == 0 {
-					return nil, nil
-				}
-
-				x, err := strconv.Atoi(string(data))
-				if err != nil {
-					return nil, err
-				}
-				return x, nil
-			}, nil
-		case graphql.Float:
-			return func(data []byte) (interface{}, error) {
-				if len(data) == 0 {
-					return nil, nil
-				}
-
-				x, err := strconv.ParseFloat(string(data), 64)
-				if err != nil {
-					return nil, err
-				}
-				return x, nil
-			}, nil
-		case graphql.Boolean:
-			return func(data []byte) (interface{}, error) {
-				if len(data) == 0 {
-					return nil, nil
-				}
-
-				switch string(data) {
-				case "True":
-					fallthrough
-				case "true":
-					return true, nil
-				case "False":
-					fallthrough
-				case "false":
-					return false, nil
-				default:
-					return nil, fmt.Errorf("non bool output: %s",
-						string(data),
-					)
-				}
-			}, nil
-		case graphql.DateTime:
-			return func(data []byte) (interface{}, error) {
-				if len(data) == 0 {
-					return nil, nil
-				}
-
-				t, err := time.Parse(time.RFC3339, string(data))
-				if err != nil {
-					return nil, err
-				}
-				return t, nil
-			}, nil
-		default:
-			return nil, fmt.Errorf("unsupported return type: %T", x)
+		if !isLeaf(x.OfType) {
+			return nil, mismatch(where, t, string(data))
 		}
-	case *graphql.Object:
-		return func(data []byte) (interface{}, error) {
-			if len(data) == 0 {
-				return nil, nil
+
+		var lines = strings.Split(string(data), "\n")
+		var list = make([]interface{}, len(lines))
+		for idx, line := range lines {
+			v, err := parseText(x.OfType, []byte(strings.TrimSuffix(line, "\r")), elementOf(idx, where))
+			if err != nil {
+				return nil, err
 			}
+			list[idx] = v
+		}
 
-			var jsonOutput interface{}
-			if err := json.Unmarshal(data, &jsonOutput); err != nil {
+		return list, nil
+	case *graphql.Scalar:
+		return parseScalarText(x, string(data), where)
+	case *graphql.Enum:
+		v, err := enumValue(x, string(data))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", where, err)
+		}
+
+		return v, nil
+	default:
+		return parseJSON(t, data, where)
+	}
+}
+
+// parseScalarText parses the plain text form s of a scalar.
+func parseScalarText(t *graphql.Scalar, s, where string) (interface{}, error) {
+	switch t {
+	case graphql.Int:
+		x, err := strconv.ParseInt(s, 10, 32)
+		if err != nil {
+			return nil, mismatch(where, t, s)
+		}
+		return int(x), nil
+	case graphql.Float:
+		x, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return nil, mismatch(where, t, s)
+		}
+		return x, nil
+	case graphql.Boolean:
+		switch s {
+		case "true", "True":
+			return true, nil
+		case "false", "False":
+			return false, nil
+		}
+		return nil, mismatch(where, t, s)
+	case graphql.DateTime:
+		x, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			return nil, mismatch(where, t, s)
+		}
+		return x, nil
+	}
+
+	// custom scalars are written out as JSON or as a plain string; their Serialize function validates them
+	return decodeScalar([]byte(s)), nil
+}
+
+// parseJSON decodes data and coerces it to t.
+func parseJSON(t graphql.Type, data []byte, where string) (interface{}, error) {
+	var (
+		v   interface{}
+		dec = json.NewDecoder(bytes.NewReader(data))
+	)
+	// numbers are kept as is until their type is known
+	dec.UseNumber()
+
+	if err := dec.Decode(&v); err != nil {
+		return nil, fmt.Errorf("%s: invalid JSON: %w", where, err)
+	}
+	if dec.More() {
+		return nil, fmt.Errorf("%s: invalid JSON: trailing data", where)
+	}
+
+	return coerceValue(t, v, where)
+}
+
+// coerceValue coerces the JSON value v to t.
+func coerceValue(t graphql.Type, v interface{}, where string) (interface{}, error) {
+	if nn, ok := t.(*graphql.NonNull); ok {
+		if v == nil {
+			return nil, mismatch(where, t, v)
+		}
+		t = nn.OfType
+	} else if v == nil {
+		return nil, nil
+	}
+
+	switch x := t.(type) {
+	case *graphql.List:
+		list, ok := v.([]interface{})
+		if !ok {
+			return nil, mismatch(where, t, v)
+		}
+
+		for idx, item := range list {
+			item, err := coerceValue(x.OfType, item, elementOf(idx, where))
+			if err != nil {
 				return nil, err
 			}
-			return jsonOutput, nil
-		}, nil
+			list[idx] = item
+		}
+
+		return list, nil
+	case *graphql.Scalar:
+		return coerceScalar(x, v, where)
+	case *graphql.Enum:
+		s, ok := v.(string)
+		if !ok {
+			return nil, mismatch(where, t, v)
+		}
+
+		v, err := enumValue(x, s)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", where, err)
+		}
+
+		return v, nil
+	case *graphql.Object, *graphql.Interface, *graphql.Union:
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return nil, mismatch(where, t, v)
+		}
+
+		// the fields of objects are resolved by graphql-go, which doesn't know about json.Number
+		return floatNumbers(m), nil
+	}
+
+	return nil, fmt.Errorf("%s: unsupported type %s", where, t)
+}
+
+// coerceScalar coerces the JSON value v to the scalar t.
+func coerceScalar(t *graphql.Scalar, v interface{}, where string) (interface{}, error) {
+	switch t {
+	case graphql.String:
+		if s, ok := v.(string); ok {
+			return s, nil
+		}
+	case graphql.ID:
+		switch x := v.(type) {
+		case string:
+			return x, nil
+		case json.Number:
+			return x.String(), nil
+		}
+	case graphql.Int:
+		if n, ok := v.(json.Number); ok {
+			if x, err := strconv.ParseInt(n.String(), 10, 32); err == nil {
+				return int(x), nil
+			}
+		}
+	case graphql.Float:
+		if n, ok := v.(json.Number); ok {
+			if x, err := n.Float64(); err == nil {
+				return x, nil
+			}
+		}
+	case graphql.Boolean:
+		if b, ok := v.(bool); ok {
+			return b, nil
+		}
+	case graphql.DateTime:
+		if s, ok := v.(string); ok {
+			if x, err := time.Parse(time.RFC3339, s); err == nil {
+				return x, nil
+			}
+		}
 	default:
-		return nil, fmt.Errorf(
-			"unsupported return type: %T",
-			x,
-		)
+		// custom scalars are validated by their Serialize function
+		return v, nil
 	}
+
+	return nil, mismatch(where, t, v)
+}
+
+// floatNumbers replaces the json.Numbers in v with float64s, as if it had been decoded without UseNumber.
+func floatNumbers(v interface{}) interface{} {
+	switch x := v.(type) {
+	case json.Number:
+		f, _ := x.Float64()
+		return f
+	case []interface{}:
+		for idx, item := range x {
+			x[idx] = floatNumbers(item)
+		}
+	case map[string]interface{}:
+		for k, item := range x {
+			x[k] = floatNumbers(item)
+		}
+	}
+
+	return v
+}
+
+// isLeaf reports whether t, ignoring NonNull, is a scalar or an enum.
+func isLeaf(t graphql.Type) bool {
+	if nn, ok := t.(*graphql.NonNull); ok {
+		t = nn.OfType
+	}
+
+	switch t.(type) {
+	case *graphql.Scalar, *graphql.Enum:
+		return true
+	}
+
+	return false
+}
+
+// enumValue checks that s represents one of the values of e.
+func enumValue(e *graphql.Enum, s string) (interface{}, error) {
+	for _, value := range e.Values() {
+		if value.Value == s {
+			return value.Value, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%q is not a value of enum %s", s, e.Name())
+}
+
+func elementOf(idx int, where string) string {
+	return fmt.Sprintf("element %d of %s", idx, where)
+}
+
+// mismatch returns the error for a value v that isn't of type t.
+func mismatch(where string, t graphql.Type, v interface{}) error {
+	return fmt.Errorf("%s: expected %s, got %s", where, t, describe(v))
+}
+
+// describe quotes v as JSON, shortening it if need be.
+func describe(v interface{}) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+
+	if maxDescribedLen < len(data) {
+		return string(data[:maxDescribedLen]) + "..."
+	}
+
+	return string(data)
 }
 
 func argStringFromValue(t graphql.Type, name string, v interface{}) (string, error) {
@@ -210,7 +342,11 @@ func argStringFromValue(t graphql.Type, name string, v interface{}) (string, err
 				fallthrough
 			case graphql.DateTime:
 				return fmt.Sprintf("%v", v), nil
+			default:
+				return customScalarArg(x, v)
 			}
+		case *graphql.Enum:
+			return fmt.Sprint(v), nil
 		case *graphql.Object:
 			data, err := json.Marshal(v)
 			if err != nil {
@@ -248,11 +384,29 @@ func argStringFromValue(t graphql.Type, name string, v interface{}) (string, err
 		case graphql.DateTime:
 			return fmt.Sprintf("%v", v), nil
 		default:
-			data, err := json.Marshal(v)
-			if err != nil {
-				return "", err
-			}
-			return string(data), nil
+			return customScalarArg(x, v)
+		}
+	case *graphql.Enum:
+		return fmt.Sprint(v), nil
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+
+	return string(data), nil
+}
+
+// customScalarArg returns the argument string for a value of a custom scalar:
+// strings and values with a textual form, such as a BigInt, are passed as is and anything else as JSON.
+func customScalarArg(s *graphql.Scalar, v interface{}) (string, error) {
+	if s != scalar.JSON {
+		switch x := v.(type) {
+		case string:
+			return x, nil
+		case fmt.Stringer:
+			return x.String(), nil
 		}
 	}
 
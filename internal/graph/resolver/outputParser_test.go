@@ -0,0 +1,185 @@
+package resolver
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/graphql-go/graphql"
+)
+
+var (
+	testColor = graphql.NewEnum(graphql.EnumConfig{
+		Name: "Color",
+		Values: graphql.EnumValueConfigMap{
+			"RED":   {Value: "RED"},
+			"GREEN": {Value: "GREEN"},
+		},
+	})
+
+	testObject = graphql.NewObject(graphql.ObjectConfig{
+		Name: "Thing",
+		Fields: graphql.Fields{
+			"name": {Type: graphql.String},
+		},
+	})
+
+	testScalar = graphql.NewScalar(graphql.ScalarConfig{
+		Name:      "Anything",
+		Serialize: func(v interface{}) interface{} { return v },
+	})
+)
+
+func TestParseText(t *testing.T) {
+	var tests = []struct {
+		name string
+		t    graphql.Type
+		data string
+
+		want interface{}
+		err  string
+	}{
+		{name: "string verbatim", t: graphql.String, data: " hi\n", want: " hi\n"},
+		{name: "empty string", t: graphql.String, data: "", want: nil},
+		{name: "empty non-null string", t: graphql.NewNonNull(graphql.String), data: "", want: ""},
+		{name: "id", t: graphql.ID, data: "42", want: "42"},
+		{name: "int", t: graphql.Int, data: " 42\n", want: 42},
+		{name: "invalid int", t: graphql.Int, data: "4.2", err: `field f: expected Int, got "4.2"`},
+		{name: "int out of range", t: graphql.Int, data: "4294967296", err: `field f: expected Int, got "4294967296"`},
+		{name: "missing int", t: graphql.Int, data: "\n", want: nil},
+		{name: "missing non-null int", t: graphql.NewNonNull(graphql.Int), data: "", err: "field f: expected Int!, got nothing"},
+		{name: "float", t: graphql.Float, data: "1.5", want: 1.5},
+		{name: "boolean", t: graphql.Boolean, data: "True", want: true},
+		{name: "invalid boolean", t: graphql.Boolean, data: "yes", err: `field f: expected Boolean, got "yes"`},
+		{
+			name: "date time",
+			t:    graphql.DateTime,
+			data: "2021-06-01T12:00:00Z",
+			want: time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC),
+		},
+		{name: "enum", t: testColor, data: "RED\n", want: "RED"},
+		{name: "unknown enum value", t: testColor, data: "BLUE", err: `field f: "BLUE" is not a value of enum Color`},
+		{name: "custom scalar as text", t: testScalar, data: "abc", want: "abc"},
+		{
+			name: "list of lines",
+			t:    graphql.NewList(graphql.Int),
+			data: "1\r\n2\n3\n",
+			want: []interface{}{1, 2, 3},
+		},
+		{
+			name: "list as json",
+			t:    graphql.NewList(graphql.Int),
+			data: "[1, 2, null]",
+			want: []interface{}{1, 2, nil},
+		},
+		{
+			name: "invalid list line",
+			t:    graphql.NewList(graphql.Int),
+			data: "1\nx",
+			err:  `element 1 of field f: expected Int, got "x"`,
+		},
+		{
+			name: "list of objects as text",
+			t:    graphql.NewList(testObject),
+			data: "a\nb",
+			err:  `field f: expected [Thing], got "a\nb"`,
+		},
+		{
+			name: "object",
+			t:    testObject,
+			data: `{"name": "a", "n": 1}`,
+			want: map[string]interface{}{"name": "a", "n": 1.0},
+		},
+		{name: "invalid object", t: testObject, data: "nope", err: "field f: invalid JSON: invalid character 'o' in literal null (expecting 'u')"},
+		{name: "trailing data", t: testObject, data: "{} {}", err: "field f: invalid JSON: trailing data"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseText(tt.t, []byte(tt.data), "field f")
+			checkParsed(t, got, err, tt.want, tt.err)
+		})
+	}
+}
+
+func TestCoerceValue(t *testing.T) {
+	var tests = []struct {
+		name string
+		t    graphql.Type
+		v    interface{}
+
+		want interface{}
+		err  string
+	}{
+		{name: "null", t: graphql.Int, v: nil, want: nil},
+		{name: "non-null null", t: graphql.NewNonNull(graphql.Int), v: nil, err: "field f: expected Int!, got null"},
+		{name: "int", t: graphql.Int, v: json.Number("7"), want: 7},
+		{name: "fractional int", t: graphql.Int, v: json.Number("1.5"), err: "field f: expected Int, got 1.5"},
+		{name: "int as string", t: graphql.Int, v: "7", err: `field f: expected Int, got "7"`},
+		{name: "float", t: graphql.Float, v: json.Number("1.5"), want: 1.5},
+		{name: "numeric id", t: graphql.ID, v: json.Number("12"), want: "12"},
+		{name: "string", t: graphql.String, v: "a", want: "a"},
+		{name: "number as string", t: graphql.String, v: json.Number("1"), err: "field f: expected String, got 1"},
+		{name: "boolean", t: graphql.Boolean, v: false, want: false},
+		{name: "enum", t: testColor, v: "GREEN", want: "GREEN"},
+		{name: "enum as number", t: testColor, v: json.Number("1"), err: "field f: expected Color, got 1"},
+		{name: "custom scalar", t: testScalar, v: map[string]interface{}{"a": true}, want: map[string]interface{}{"a": true}},
+		{
+			name: "list",
+			t:    graphql.NewList(graphql.NewNonNull(graphql.String)),
+			v:    []interface{}{"a", "b"},
+			want: []interface{}{"a", "b"},
+		},
+		{
+			name: "null in a list of non-nulls",
+			t:    graphql.NewList(graphql.NewNonNull(graphql.String)),
+			v:    []interface{}{"a", nil},
+			err:  "element 1 of field f: expected String!, got null",
+		},
+		{
+			name: "nested lists",
+			t:    graphql.NewList(graphql.NewList(graphql.Int)),
+			v:    []interface{}{[]interface{}{json.Number("1")}, []interface{}{"x"}},
+			err:  `element 0 of element 1 of field f: expected Int, got "x"`,
+		},
+		{
+			name: "object with numbers",
+			t:    testObject,
+			v:    map[string]interface{}{"n": json.Number("2"), "l": []interface{}{json.Number("3")}},
+			want: map[string]interface{}{"n": 2.0, "l": []interface{}{3.0}},
+		},
+		{name: "object as list", t: testObject, v: []interface{}{}, err: "field f: expected Thing, got []"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := coerceValue(tt.t, tt.v, "field f")
+			checkParsed(t, got, err, tt.want, tt.err)
+		})
+	}
+}
+
+func checkParsed(t *testing.T, got interface{}, err error, want interface{}, wantErr string) {
+	t.Helper()
+
+	if wantErr != "" {
+		if err == nil {
+			t.Fatalf("expected error %q, got %#v", wantErr, got)
+		}
+
+		if err.Error() != wantErr {
+			t.Fatalf("expected error %q, got %q", wantErr, err)
+		}
+
+		return
+	}
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %#v, got %#v", want, got)
+	}
+}
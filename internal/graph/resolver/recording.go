@@ -0,0 +1,323 @@
+package resolver
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/raphaelreyna/graphqld/internal/middleware"
+)
+
+// unrecordedEnv are the variables left out of recordings as they hold credentials.
+var unrecordedEnv = map[string]struct{}{
+	"HTTP_AUTHORIZATION":       {},
+	"HTTP_PROXY_AUTHORIZATION": {},
+	"HTTP_COOKIE":              {},
+}
+
+// Call is a resolver process as it was run, kept one per line in a recording.
+type Call struct {
+	Time time.Time `json:"time"`
+
+	// Path is the path of the resolver and Argv what it was run with, before being sandboxed.
+	Path string   `json:"path"`
+	Argv []string `json:"argv"`
+
+	Env   []string `json:"env"`
+	Stdin string   `json:"stdin,omitempty"`
+
+	// Context holds the contents of the context file, if the resolver was given one.
+	Context string `json:"context,omitempty"`
+
+	Stdout string `json:"stdout"`
+	Stderr string `json:"stderr,omitempty"`
+
+	// Errors holds what the resolver wrote to its errors file.
+	Errors string `json:"errors,omitempty"`
+
+	ExitCode int           `json:"exitCode"`
+	Duration time.Duration `json:"duration"`
+}
+
+// key identifies the calls that can be replayed in place of running a resolver;
+// calls are told apart by what they were run with, as their environment differs from one request to the next.
+func (c *Call) key() string {
+	return strings.Join(append([]string{c.Stdin}, c.Argv...), "\x00")
+}
+
+// recording is a file calls are appended to, shared by every tape recording to it so that their lines don't interleave.
+type recording struct {
+	sync.Mutex
+
+	path string
+	w    io.WriteCloser
+
+	// tapes is how many open tapes record to the file, which is closed once none do.
+	tapes int
+}
+
+// recordings are the files being recorded to by their absolute path.
+var recordings = struct {
+	sync.Mutex
+	m map[string]*recording
+}{m: make(map[string]*recording)}
+
+// Tape records the resolver processes run for a graph, or replays them from a recording in place of running them.
+type Tape struct {
+	sync.Mutex
+
+	// rec is where calls are recorded to.
+	rec *recording
+
+	// calls are the recorded calls left to replay by key; the last one of each is never used up.
+	calls map[string][]*Call
+
+	// closed is set once the recording is closed; calls ending after that aren't recorded.
+	closed bool
+}
+
+// NewRecordingTape returns a Tape appending the resolver processes it's given to the file at path.
+// Tapes recording to the same file, such as those of several graphs or of a graph and its rebuild, share it;
+// the file is closed once all of them are.
+func NewRecordingTape(path string) (*Tape, error) {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open recording: %w", err)
+	}
+
+	recordings.Lock()
+	defer recordings.Unlock()
+
+	rec, ok := recordings.m[path]
+	if !ok {
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+		if err != nil {
+			return nil, fmt.Errorf("unable to open recording: %w", err)
+		}
+
+		rec = &recording{path: path, w: f}
+		recordings.m[path] = rec
+	}
+	rec.tapes++
+
+	return &Tape{rec: rec}, nil
+}
+
+// NewReplayTape returns a Tape replaying the resolver processes recorded in the file at path.
+// Calls run with the same arguments and standard input are replayed in the order they were recorded.
+func NewReplayTape(path string) (*Tape, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open recording: %w", err)
+	}
+	defer f.Close()
+
+	var (
+		t       = Tape{calls: make(map[string][]*Call)}
+		scanner = bufio.NewScanner(f)
+		line    int
+	)
+	scanner.Buffer(nil, 64*1024*1024)
+
+	for scanner.Scan() {
+		line++
+
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+
+		var call Call
+		if err := json.Unmarshal(scanner.Bytes(), &call); err != nil {
+			return nil, fmt.Errorf("%s:%d: invalid recorded call: %w", path, line, err)
+		}
+
+		var key = call.key()
+		t.calls[key] = append(t.calls[key], &call)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("unable to read recording: %w", err)
+	}
+
+	return &t, nil
+}
+
+// Close closes the recording, if recording.
+// Resolvers still running, such as those of requests being served by a graph that was just rebuilt, are left unrecorded.
+func (t *Tape) Close() error {
+	t.Lock()
+	defer t.Unlock()
+
+	if t.rec == nil || t.closed {
+		return nil
+	}
+	t.closed = true
+
+	recordings.Lock()
+	defer recordings.Unlock()
+
+	if t.rec.tapes--; 0 < t.rec.tapes {
+		return nil
+	}
+	delete(recordings.m, t.rec.path)
+
+	t.rec.Lock()
+	defer t.rec.Unlock()
+
+	return t.rec.w.Close()
+}
+
+func (t *Tape) replaying() bool {
+	return t.calls != nil
+}
+
+func (t *Tape) record(call *Call) error {
+	data, err := json.Marshal(call)
+	if err != nil {
+		return err
+	}
+
+	t.Lock()
+	defer t.Unlock()
+
+	if t.closed {
+		return nil
+	}
+
+	t.rec.Lock()
+	defer t.rec.Unlock()
+
+	_, err = t.rec.w.Write(append(data, '\n'))
+
+	return err
+}
+
+// Taped reports whether every process b runs for a field of objName is recorded and replayed by its tape.
+// Only executables run once per field resolution are: worker pools, FastCGI and HTTP resolvers,
+// and the executables of subscription fields streaming events aren't.
+func Taped(objName string, b Backend) bool {
+	switch b := b.(type) {
+	case *batchExecBackend:
+		return b.tape != nil
+	case *execBackend:
+		return objName != "Subscription" && b.tape != nil
+	case *topicBackend:
+		return b.filter == nil || b.filter.tape != nil
+	default:
+		return false
+	}
+}
+
+// replay returns the recorded call to replay in place of running call.
+func (t *Tape) replay(call *Call) (*Call, error) {
+	t.Lock()
+	defer t.Unlock()
+
+	var (
+		key   = call.key()
+		calls = t.calls[key]
+	)
+	if len(calls) == 0 {
+		return nil, fmt.Errorf("no recorded call of %s with arguments %q and the same standard input", call.Path, call.Argv[1:])
+	}
+
+	if 1 < len(calls) {
+		t.calls[key] = calls[1:]
+	}
+
+	return calls[0], nil
+}
+
+// newCall describes the command c is about to run; it leaves c able to read its standard input afterwards.
+func (c *command) newCall() (*Call, error) {
+	var call = Call{
+		Time: time.Now(),
+		Path: c.Path,
+		Argv: append([]string{}, c.Args...),
+		Env:  make([]string, 0, len(c.Env)),
+	}
+
+	for _, kv := range c.Env {
+		if _, ok := unrecordedEnv[strings.SplitN(kv, "=", 2)[0]]; !ok {
+			call.Env = append(call.Env, kv)
+		}
+	}
+
+	if c.Stdin != nil {
+		stdin, err := ioutil.ReadAll(c.Stdin)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read resolver input: %w", err)
+		}
+		c.Stdin = bytes.NewReader(stdin)
+		call.Stdin = string(stdin)
+	}
+
+	if f := middleware.GetCtxFile(c.ctx); f != nil {
+		data, err := ioutil.ReadFile(f.Name())
+		if err != nil {
+			return nil, fmt.Errorf("unable to read context file: %w", err)
+		}
+		call.Context = string(data)
+	}
+
+	return &call, nil
+}
+
+// recordRun completes call with how the command went once it has exited, and records it.
+func (c *command) recordRun(call *Call, stdout, stderr []byte) error {
+	call.Duration = time.Since(call.Time)
+	call.Stdout = string(stdout)
+	call.Stderr = string(stderr)
+	call.ExitCode = c.ProcessState.ExitCode()
+
+	if c.errFile != nil {
+		errs, err := readAll(c.errFile)
+		if err != nil {
+			return err
+		}
+		call.Errors = string(errs)
+	}
+
+	return c.tape.record(call)
+}
+
+// replayRun returns what the recorded run of the command output, as output would.
+func (c *command) replayRun(call *Call) ([]byte, error) {
+	recorded, err := c.tape.replay(call)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.errFile != nil {
+		if _, err := c.errFile.WriteString(recorded.Errors); err != nil {
+			return nil, err
+		}
+	}
+
+	if recorded.ExitCode != 0 {
+		return nil, &exitError{
+			status: recorded.ExitCode,
+			errs:   parseErrors([]byte(recorded.Stderr)),
+			argv:   call.Argv,
+			stderr: []byte(recorded.Stderr),
+		}
+	}
+
+	return []byte(recorded.Stdout), nil
+}
+
+// readAll returns the contents of f from its start.
+func readAll(f *os.File) ([]byte, error) {
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	return ioutil.ReadAll(io.LimitReader(f, maxErrorsSize))
+}
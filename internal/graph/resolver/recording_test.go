@@ -0,0 +1,179 @@
+package resolver
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/raphaelreyna/graphqld/internal/config"
+)
+
+func TestTapeRecordAfterClose(t *testing.T) {
+	var path = filepath.Join(t.TempDir(), "recording.jsonl")
+
+	tape, err := NewRecordingTape(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := tape.record(&Call{Path: "/a", Argv: []string{"/a"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := tape.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// resolvers still running once the graph is closed are left out
+	if err := tape.record(&Call{Path: "/b", Argv: []string{"/b"}}); err != nil {
+		t.Fatalf("expected calls ending after close to be dropped, got %v", err)
+	}
+
+	if err := tape.Close(); err != nil {
+		t.Fatalf("expected closing twice to do nothing, got %v", err)
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if n := bytes.Count(data, []byte("\n")); n != 1 {
+		t.Fatalf("expected 1 recorded call, got %d: %s", n, data)
+	}
+
+	replay, err := NewReplayTape(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := replay.replay(&Call{Argv: []string{"/a"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := replay.replay(&Call{Path: "/b", Argv: []string{"/b"}}); err == nil {
+		t.Fatal("expected the call made after close not to be replayed")
+	}
+}
+
+func TestTapesShareRecording(t *testing.T) {
+	var (
+		dir  = t.TempDir()
+		path = filepath.Join(dir, "recording.jsonl")
+	)
+
+	// a graph and its rebuild, the second one being opened with a relative path
+	old, err := NewRecordingTape(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	rebuilt, err := NewRecordingTape("recording.jsonl")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if old.rec != rebuilt.rec {
+		t.Fatal("expected tapes recording to the same file to share it")
+	}
+
+	var wg sync.WaitGroup
+	for _, tape := range []*Tape{old, rebuilt} {
+		for idx := 0; idx < 50; idx++ {
+			wg.Add(1)
+			go func(tape *Tape, idx int) {
+				defer wg.Done()
+
+				var path = fmt.Sprintf("/%d", idx)
+				if err := tape.record(&Call{Path: path, Argv: []string{path}, Stdout: string(bytes.Repeat([]byte("x"), 1<<12))}); err != nil {
+					t.Error(err)
+				}
+			}(tape, idx)
+		}
+	}
+	wg.Wait()
+
+	if err := old.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// the rebuild keeps recording once the previous build is closed
+	if err := rebuilt.record(&Call{Path: "/last", Argv: []string{"/last"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := rebuilt.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := recordings.m[path]; ok {
+		t.Error("expected the recording to be closed once every tape is")
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	var (
+		scanner = bufio.NewScanner(f)
+		lines   int
+	)
+	scanner.Buffer(nil, 1<<20)
+	for scanner.Scan() {
+		var call Call
+		if err := json.Unmarshal(scanner.Bytes(), &call); err != nil {
+			t.Fatalf("expected every line to be a call, got %v", err)
+		}
+		lines++
+	}
+
+	if lines != 101 {
+		t.Fatalf("expected 101 recorded calls, got %d", lines)
+	}
+}
+
+func TestTaped(t *testing.T) {
+	var (
+		tape = &Tape{}
+		c    = &config.GraphConf{}
+	)
+
+	var tests = []struct {
+		name    string
+		objName string
+		b       Backend
+		want    bool
+	}{
+		{name: "exec", objName: "Query", b: NewExecBackend("/a", "", config.Limits{}, tape, c), want: true},
+		{name: "exec without a tape", objName: "Query", b: NewExecBackend("/a", "", config.Limits{}, nil, c), want: false},
+		{name: "batch", objName: "User", b: NewBatchExecBackend("/a", "", config.Limits{}, tape, c), want: true},
+		{name: "stream", objName: "Subscription", b: NewExecBackend("/a", "", config.Limits{}, tape, c), want: false},
+		{name: "topic", objName: "Subscription", b: &topicBackend{topic: "t"}, want: true},
+		{name: "topic filter", objName: "Subscription", b: &topicBackend{topic: "t", filter: &execBackend{tape: tape}}, want: true},
+		{name: "fastcgi", objName: "Query", b: NewFastCGIBackend(config.FastCGIConf{}, config.Limits{}), want: false},
+		{name: "worker", objName: "Query", b: &WorkerPool{}, want: false},
+	}
+
+	for _, tt := range tests {
+		if got := Taped(tt.objName, tt.b); got != tt.want {
+			t.Errorf("%s: expected %t, got %t", tt.name, tt.want, got)
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package resolver
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/graphql-go/graphql"
+	"github.com/raphaelreyna/graphqld/internal/config"
+	"github.com/raphaelreyna/graphqld/internal/middleware"
+)
+
+// ResolveTypeName is the name of the executable, in the directory of an interface or union,
+// that tells which object a value of that type is.
+const ResolveTypeName = "__resolveType"
+
+// typenameKey is the key holding the name of the concrete type of a value in a resolvers output.
+const typenameKey = "__typename"
+
+// NewResolveTypeFn returns the function resolving the concrete type of values of the abstract type typeName.
+// Values name their own type with a __typename key; for those that don't, the executable at path,
+// if any, is run with the value on stdin and must output the name of its type.
+// objects looks up the objects of the graph by name. Runs of the executable are recorded to or replayed from t, if it's not nil.
+func NewResolveTypeFn(typeName, path string, objects func(string) *graphql.Object, t *Tape, c *config.GraphConf) graphql.ResolveTypeFn {
+	var eb *execBackend
+	if path != "" {
+		eb = &execBackend{
+			path:    path,
+			wd:      c.ResolverDir,
+			user:    c.User,
+			sandbox: c.Sandbox,
+			limits:  c.Limits,
+			tape:    t,
+		}
+	}
+
+	var (
+		timeout    = c.ResolverTimeout
+		maskErrors = c.MaskErrors
+	)
+
+	// failures are raised as panics which graphql-go reports as the fields error
+	var fail = func(ctx context.Context, err error) {
+		logEvent := middleware.GetLogger(ctx).Warn().Err(err).
+			Str("type", typeName)
+		if eb != nil {
+			logEvent.Str("resolver", eb.String())
+		}
+
+		if maskErrors {
+			var id = newErrorID()
+			logEvent.Str("error-id", id)
+			err = reportedErrors{{message: err.Error()}}.mask(id)
+		}
+
+		logEvent.Msg("unable to resolve type")
+
+		panic(err)
+	}
+
+	return func(p graphql.ResolveTypeParams) *graphql.Object {
+		var name string
+		if m, ok := p.Value.(map[string]interface{}); ok {
+			name, _ = m[typenameKey].(string)
+		}
+
+		if name == "" && eb != nil {
+			var err error
+			if name, err = runResolveType(p, eb, typeName, timeout); err != nil {
+				fail(p.Context, err)
+			}
+		}
+
+		if name == "" {
+			fail(p.Context, fmt.Errorf(
+				"unable to resolve the type of a %s value: missing %s",
+				typeName, typenameKey,
+			))
+		}
+
+		obj := objects(name)
+		if obj == nil {
+			fail(p.Context, fmt.Errorf(
+				"unable to resolve the type of a %s value: unknown type %q",
+				typeName, name,
+			))
+		}
+
+		return obj
+	}
+}
+
+// runResolveType runs the resolve-type executable eb for the value in p, returning the type name it output.
+func runResolveType(p graphql.ResolveTypeParams, eb *execBackend, typeName string, timeout time.Duration) (string, error) {
+	var ctx = p.Context
+
+	release, err := middleware.AcquireResolverSlot(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer release()
+
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	out, err := eb.run(ctx, &invocation{
+		objName:   typeName,
+		fieldName: ResolveTypeName,
+		args:      map[string]string{},
+		params: graphql.ResolveParams{
+			Source:  p.Value,
+			Info:    p.Info,
+			Context: ctx,
+		},
+	})
+	if err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return "", fmt.Errorf("%s timed out after %s", eb.path, timeout)
+		}
+
+		return "", err
+	}
+
+	return string(bytes.TrimSpace(out.body)), nil
+}
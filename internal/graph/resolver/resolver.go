@@ -1,32 +1,38 @@
 package resolver
 
 import (
-	"bufio"
 	"bytes"
-	"encoding/json"
+	"context"
 	"errors"
 	"fmt"
-	"io"
-	"net/textproto"
-	"os"
-	"os/exec"
-	"path/filepath"
-	"syscall"
+	"strings"
 
 	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
 	"github.com/raphaelreyna/graphqld/internal/config"
 	"github.com/raphaelreyna/graphqld/internal/middleware"
 )
 
-func NewFieldResolveFn(path, wd string, field *graphql.FieldDefinition, c *config.GraphConf) (*graphql.FieldResolveFn, error) {
+// NewFieldResolveFn creates a resolver for the field objName.field that uses b each time the field is resolved.
+func NewFieldResolveFn(objName string, field *graphql.FieldDefinition, b Backend, c *config.GraphConf) (*graphql.FieldResolveFn, error) {
 	var (
 		takesArgs  = 0 < len(field.Args)
-		scriptName = filepath.Base(path)
-		objName    = filepath.Base(filepath.Dir(path))
 		fieldName  = field.Name
+		timeout    = c.ResolverTimeout
+		codes      = c.ErrorCodes
+		maskErrors = c.MaskErrors
+		namedArgs  = make(map[string]*graphql.Argument)
 	)
 
-	parseOutput, err := newOutputParser(field.Type)
+	for _, arg := range field.Args {
+		namedArgs[arg.Name()] = arg
+	}
+
+	if fc, ok := c.Fields[objName+"."+fieldName]; ok && fc.Timeout != nil {
+		timeout = *fc.Timeout
+	}
+
+	parseOutput, err := newOutputParser(fieldName, field.Type)
 	if err != nil {
 		return nil, fmt.Errorf(
 			"NewFieldResolveFn:: error creating output parser for field %s: %w",
@@ -34,117 +40,293 @@ func NewFieldResolveFn(path, wd string, field *graphql.FieldDefinition, c *confi
 		)
 	}
 
-	var f = func(p graphql.ResolveParams) (interface{}, error) {
-		var (
-			ctx = p.Context
+	var (
+		withTimeout = func(ctx context.Context) (context.Context, context.CancelFunc) {
+			if timeout > 0 {
+				return context.WithTimeout(ctx, timeout)
+			}
 
-			args      = make([]string, 0)
-			namedArgs = make(map[string]*graphql.Argument)
+			return context.WithCancel(ctx)
+		}
 
-			logger = middleware.GetLogger(ctx)
-		)
+		// describeErr logs err and turns it into the error reported to the client.
+		describeErr = func(ctx context.Context, err error) error {
+			logEvent := middleware.GetLogger(ctx).Warn().Err(err).
+				Str("object", objName).
+				Str("field", fieldName).
+				Str("resolver", b.String())
 
-		for _, arg := range field.Args {
-			namedArgs[arg.Name()] = arg
-		}
+			switch ctx.Err() {
+			case context.DeadlineExceeded:
+				logEvent.Dur("timeout", timeout).
+					Msg("resolver timed out")
 
-		if takesArgs {
-			for name, arg := range p.Args {
-				argType := namedArgs[name].Type
-				argStr, err := argStringFromValue(argType, name, arg)
-				if err != nil {
-					return nil, err
+				return fmt.Errorf(
+					"field %s.%s timed out after %s",
+					objName, fieldName, timeout,
+				)
+			case context.Canceled:
+				logEvent.Msg("resolver cancelled")
+
+				return fmt.Errorf(
+					"field %s.%s cancelled: %w",
+					objName, fieldName, context.Canceled,
+				)
+			}
+
+			var (
+				exitErr *exitError
+				outErr  *outputError
+				repErrs reportedErrors
+				errs    reportedErrors
+				msg     = "resolver reported error"
+			)
+			switch {
+			case errors.As(err, &outErr):
+				msg = "invalid resolver output"
+				errs = reportedErrors{{message: err.Error()}}
+			case errors.As(err, &exitErr):
+				logEvent.Int("status", exitErr.status)
+				if exitErr.argv != nil {
+					logEvent.Strs("argv", exitErr.argv)
 				}
+				if exitErr.stderr != nil {
+					logEvent.Str("stderr", string(exitErr.stderr))
+				}
+
+				errs = exitErr.reported().withCode(codes[exitErr.status])
+			case errors.As(err, &repErrs):
+				errs = repErrs
+			case maskErrors:
+				msg = "unable to run resolver"
+				errs = reportedErrors{{message: err.Error()}}
+			default:
+				logEvent.Msg("unable to run resolver")
+				return err
+			}
 
-				args = append(args, "--"+name, argStr)
+			if maskErrors {
+				var id = newErrorID()
+				logEvent.Str("error-id", id)
+				errs = errs.mask(id)
 			}
+
+			logEvent.Msg(msg)
+
+			return errs
 		}
 
-		cmd := exec.Command(path, args...)
-		if p.Source != nil {
-			source, err := json.Marshal(p.Source)
+		// parse turns a resolvers output into the value of the field.
+		parse = func(ctx context.Context, data []byte) (interface{}, error) {
+			v, err := parseOutput(data)
 			if err != nil {
-				logger.Warn().Err(err).
+				return nil, describeErr(ctx, &outputError{err: err})
+			}
+
+			return v, nil
+		}
+
+		// run runs b once a resolver slot is free for the request.
+		run = func(ctx context.Context, inv *invocation) (*output, error) {
+			release, err := middleware.AcquireResolverSlot(ctx)
+			if err != nil {
+				return nil, describeErr(ctx, err)
+			}
+			defer release()
+
+			ctx, cancel := withTimeout(ctx)
+			defer cancel()
+
+			out, err := b.run(ctx, inv)
+			if err != nil {
+				return nil, describeErr(ctx, err)
+			}
+
+			return out, nil
+		}
+
+		// publish sends a resolvers output to the subscribers of the topics it listed.
+		publish = func(ctx context.Context, topics []string, payload []byte) {
+			payload = bytes.TrimSpace(payload)
+			for _, v := range topics {
+				for _, topic := range strings.Split(v, ",") {
+					if topic = strings.TrimSpace(topic); topic == "" {
+						continue
+					}
+
+					if missed := middleware.Publish(ctx, topic, payload); 0 < missed {
+						middleware.GetLogger(ctx).Warn().
+							Str("object", objName).
+							Str("field", fieldName).
+							Str("topic", topic).
+							Int("missed", missed).
+							Msg("subscribers missed an event")
+					}
+				}
+			}
+		}
+
+		// finish must be called from the goroutine executing the query
+		// since it writes to the response header.
+		finish = func(p graphql.ResolveParams, out *output) (interface{}, error) {
+			if topics := out.header.Values(publishHeader); 0 < len(topics) {
+				out.header.Del(publishHeader)
+				publish(p.Context, topics, out.body)
+			}
+
+			if 0 < len(out.header) {
+				h := middleware.GetWHeader(p.Context)
+				for k, vv := range out.header {
+					for _, v := range vv {
+						h.Add(k, v)
+					}
+				}
+			}
+
+			if errs := out.errs; 0 < len(errs) {
+				logEvent := middleware.GetLogger(p.Context).Warn().Err(errs).
 					Str("object", objName).
 					Str("field", fieldName).
-					Msg("error encoding resolver source as JSON")
+					Str("resolver", b.String())
 
-				return nil, err
+				if maskErrors {
+					var id = newErrorID()
+					logEvent.Str("error-id", id)
+					errs = errs.mask(id)
+				}
+
+				logEvent.Msg("resolver reported errors alongside data")
+
+				middleware.AddErrors(p.Context, gqlerrors.FormatError(
+					graphql.NewLocatedErrorWithPath(
+						errs,
+						graphql.FieldASTsToNodeASTs(p.Info.FieldASTs),
+						p.Info.Path.AsArray(),
+					),
+				))
 			}
 
-			cmd.Stdin = bytes.NewReader(source)
+			return parse(p.Context, out.body)
 		}
 
-		if user := c.User; user != nil {
-			cmd.SysProcAttr = &syscall.SysProcAttr{
-				Credential: &syscall.Credential{
-					Uid: user.Uid,
-					Gid: user.Gid,
-				},
-			}
+		l *loader
+	)
+
+	if bb, ok := b.(batchBackend); ok {
+		l = newLoader(bb, withTimeout, describeErr)
+	}
+
+	// the fields of the Subscription object are resolved once for each event their resolver emits
+	var sb streamBackend
+	if objName == "Subscription" {
+		var ok bool
+		if sb, ok = b.(streamBackend); !ok || l != nil {
+			return nil, fmt.Errorf(
+				"NewFieldResolveFn:: resolver %s for field Subscription.%s can't stream events",
+				b, fieldName,
+			)
 		}
+	}
 
-		env := middleware.GetEnv(ctx)
-		env = append(env,
-			"SCRIPT_NAME="+scriptName,
-			"SCRIPT_FILENAME="+path,
-		)
-		cmd.Env = env
+	// subscribe starts the resolver of a subscription field, which runs until the subscription is over.
+	var subscribe = func(p graphql.ResolveParams, inv *invocation) (interface{}, error) {
+		if event, ok := getEvent(p.Context); ok {
+			return parse(p.Context, event)
+		}
 
-		if ctxFile := middleware.GetCtxFile(p.Context); ctxFile != nil {
-			cmd.ExtraFiles = []*os.File{ctxFile}
+		s := getSubscriber(p.Context)
+		if s == nil {
+			return nil, ErrNotStreamed
 		}
 
-		if wd != "" {
-			cmd.Dir = wd
+		if s.stream != nil {
+			return nil, errors.New("a subscription must select exactly one field")
 		}
 
-		output, err := cmd.Output()
+		stream, err := sb.stream(p.Context, inv, describeErr)
 		if err != nil {
-			logEvent := logger.Warn().Err(err).
-				Str("object", objName).
-				Str("field", fieldName).
-				Str("resolver", path).
-				Str("resolver-dir", wd)
+			return nil, describeErr(p.Context, err)
+		}
+		stream.nodes = graphql.FieldASTsToNodeASTs(p.Info.FieldASTs)
+		stream.path = p.Info.Path.AsArray()
+		s.stream = stream
 
-			exitErr, ok := err.(*exec.ExitError)
-			if !ok {
-				logEvent.Msg("unable to run resolver")
-				return nil, err
-			}
+		return nil, nil
+	}
 
-			logEvent.Msg("resolver reported error")
+	var f = func(p graphql.ResolveParams) (interface{}, error) {
+		var inv = invocation{
+			objName:   objName,
+			fieldName: fieldName,
+			args:      make(map[string]string),
+			params:    p,
+		}
 
-			return nil, errors.New(string(exitErr.Stderr))
+		if takesArgs {
+			for name, arg := range p.Args {
+				argType := namedArgs[name].Type
+				argStr, err := argStringFromValue(argType, name, arg)
+				if err != nil {
+					return nil, err
+				}
+
+				inv.args[name] = argStr
+			}
 		}
 
-		parts := bytes.SplitN(output, []byte("\n\n"), 2)
-		if len(parts) == 2 {
-			output = parts[1]
-			tpReader := textproto.NewReader(
-				bufio.NewReader(
-					bytes.NewReader(parts[0]),
-				),
-			)
+		if sb != nil {
+			return subscribe(p, &inv)
+		}
 
-			header, err := tpReader.ReadMIMEHeader()
-			if err != nil && !errors.Is(err, io.EOF) {
-				logger.Warn().Err(err).
-					Str("object", objName).
-					Str("field", fieldName).
-					Msg("unable to read MIME Header from resolver output")
+		if l != nil {
+			// resolved once every sibling has been collected
+			var load = l.load(p.Context, &inv)
+			return func() (interface{}, error) {
+				out, err := load()
+				if err != nil {
+					return nil, err
+				}
 
+				return finish(p, out)
+			}, nil
+		}
+
+		// the fields of the Mutation object must be resolved one after another
+		if objName == "Mutation" {
+			out, err := run(p.Context, &inv)
+			if err != nil {
 				return nil, err
 			}
 
-			h := middleware.GetWHeader(p.Context)
-			for k := range header {
-				h.Add(k, header.Get(k))
-			}
+			return finish(p, out)
 		}
 
-		return parseOutput(output)
+		// start resolving now so that sibling fields resolve in parallel
+		var (
+			done = make(chan struct{})
+			out  *output
+			err  error
+		)
+		go func() {
+			defer close(done)
+			out, err = run(p.Context, &inv)
+		}()
+
+		return func() (interface{}, error) {
+			<-done
+			if err != nil {
+				return nil, err
+			}
+
+			return finish(p, out)
+		}, nil
 	}
 	var ff = graphql.FieldResolveFn(f)
 	return &ff, nil
 }
+
+// timeoutFunc derives the context a single resolver run is bound to.
+type timeoutFunc func(context.Context) (context.Context, context.CancelFunc)
+
+// errFunc turns an error from a backend into the error reported to the client.
+type errFunc func(context.Context, error) error
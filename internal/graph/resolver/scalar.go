@@ -0,0 +1,129 @@
+package resolver
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"syscall"
+	"time"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/raphaelreyna/graphqld/internal/config"
+	"github.com/raphaelreyna/graphqld/internal/scalar"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	serializeFlag = "--graphqld-serialize"
+	parseFlag     = "--graphqld-parse"
+)
+
+// defaultCoerceTimeout bounds coercion executables when the graph has no resolver timeout.
+const defaultCoerceTimeout = 10 * time.Second
+
+// NewExecScalar returns a scalar whose values are coerced by the executable at path.
+// The executable gets a value as JSON on stdin and --graphqld-serialize when it's output by a resolver,
+// or --graphqld-parse when it's an argument; it writes the coerced value to stdout, as JSON or as a plain string,
+// and exits with a non-zero status if the value is invalid.
+// Its runs are recorded to or replayed from t, if it's not nil.
+func NewExecScalar(name, description, path string, t *Tape, c *config.GraphConf) *graphql.Scalar {
+	var eb = execBackend{
+		path:    path,
+		wd:      c.ResolverDir,
+		user:    c.User,
+		sandbox: c.Sandbox,
+		limits:  c.Limits,
+		tape:    t,
+	}
+
+	var timeout = c.ResolverTimeout
+	if timeout <= 0 {
+		timeout = defaultCoerceTimeout
+	}
+
+	var parse = func(value interface{}) interface{} {
+		v, err := eb.coerce(parseFlag, value, timeout)
+		if err != nil {
+			log.Debug().Err(err).
+				Str("scalar", name).
+				Str("coercer", path).
+				Msg("invalid scalar value")
+
+			return nil
+		}
+
+		return v
+	}
+
+	return graphql.NewScalar(graphql.ScalarConfig{
+		Name:        name,
+		Description: description,
+		Serialize: func(value interface{}) interface{} {
+			v, err := eb.coerce(serializeFlag, value, timeout)
+			if err != nil {
+				// graphql-go reports panics as the fields error
+				panic(fmt.Errorf("%s cannot represent %v: %w", name, value, err))
+			}
+
+			return v
+		},
+		ParseValue: parse,
+		ParseLiteral: func(valueAST ast.Value) interface{} {
+			return parse(scalar.ValueFromLiteral(valueAST))
+		},
+	})
+}
+
+// coerce runs the executable with flag and value as JSON on stdin, returning what it output.
+// Scalar coercion isn't given a context by graphql-go, so the process is only bound by timeout.
+func (eb *execBackend) coerce(flag string, value interface{}, timeout time.Duration) (interface{}, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	cmd := newCommand(ctx, eb.path, flag)
+	cmd.limits = eb.limits
+	cmd.sandbox = eb.sandbox
+	cmd.tape = eb.tape
+	cmd.Stdin = bytes.NewReader(data)
+	cmd.Dir = eb.wd
+
+	if user := eb.user; user != nil {
+		cmd.SysProcAttr.Credential = &syscall.Credential{
+			Uid: user.Uid,
+			Gid: user.Gid,
+		}
+	}
+
+	out, err := cmd.output()
+	if err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return nil, fmt.Errorf("%s timed out after %s", eb.path, timeout)
+		}
+
+		return nil, err
+	}
+
+	return decodeScalar(bytes.TrimSpace(out)), nil
+}
+
+// decodeScalar returns the value of a custom scalar written out as JSON or as a plain string.
+func decodeScalar(data []byte) interface{} {
+	var (
+		v   interface{}
+		dec = json.NewDecoder(bytes.NewReader(data))
+	)
+	dec.UseNumber()
+
+	if err := dec.Decode(&v); err != nil || dec.More() {
+		return string(data)
+	}
+
+	return v
+}
@@ -0,0 +1,184 @@
+package resolver
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/raphaelreyna/graphqld/internal/limits"
+	"github.com/raphaelreyna/graphqld/internal/middleware"
+)
+
+// ErrNotStreamed is returned when a subscription is executed as a plain query.
+var ErrNotStreamed = errors.New("subscriptions are only served over WebSockets and server-sent events")
+
+type subscriptionKey uint
+
+const (
+	keySubscriber subscriptionKey = iota
+	keyEvent
+)
+
+// streamBackend is a Backend whose resolvers can keep running, emitting one event per line.
+type streamBackend interface {
+	Backend
+
+	stream(ctx context.Context, inv *invocation, describeErr errFunc) (*Stream, error)
+}
+
+// Stream is a running subscription resolver.
+type Stream struct {
+	events chan []byte
+	err    error
+
+	// where the subscribed field is in the operation, for errors
+	nodes []ast.Node
+	path  []interface{}
+}
+
+// Events returns the events emitted by the resolver; it is closed once the resolver exits.
+func (s *Stream) Events() <-chan []byte {
+	return s.events
+}
+
+// Errors returns the errors the resolver exited with, ready to be sent to the client, once Events is closed.
+func (s *Stream) Errors() []gqlerrors.FormattedError {
+	if s.err == nil {
+		return nil
+	}
+
+	return FormatErrors([]gqlerrors.FormattedError{
+		gqlerrors.FormatError(graphql.NewLocatedErrorWithPath(s.err, s.nodes, s.path)),
+	})
+}
+
+// Subscriber receives the stream started while executing a subscription operation.
+type Subscriber struct {
+	stream *Stream
+}
+
+// Stream returns the stream started for the subscription, if any.
+func (s *Subscriber) Stream() *Stream {
+	return s.stream
+}
+
+// WithSubscriber returns a context in which executing a subscription operation starts
+// the resolver of its field and hands its stream to the returned Subscriber rather than resolving it.
+// The resolver runs until ctx is done.
+func WithSubscriber(ctx context.Context) (context.Context, *Subscriber) {
+	var s Subscriber
+	return context.WithValue(ctx, keySubscriber, &s), &s
+}
+
+// WithEvent returns a context in which executing a subscription operation resolves its field to event.
+func WithEvent(ctx context.Context, event []byte) context.Context {
+	return context.WithValue(ctx, keyEvent, event)
+}
+
+func getSubscriber(ctx context.Context) *Subscriber {
+	s, _ := ctx.Value(keySubscriber).(*Subscriber)
+	return s
+}
+
+func getEvent(ctx context.Context) ([]byte, bool) {
+	event, ok := ctx.Value(keyEvent).([]byte)
+	return event, ok
+}
+
+// stream starts the executable as a long running process; every line it writes to stdout is an event.
+// Once it exits, errors are reported the same way as by a one-shot resolver.
+// The process holds a resolver slot of the graph until it exits.
+func (eb *execBackend) stream(ctx context.Context, inv *invocation, describeErr errFunc) (*Stream, error) {
+	release, err := middleware.AcquireStreamSlot(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	cmd, errFile, closeFiles, err := eb.command(ctx, inv)
+	if err != nil {
+		release()
+		return nil, err
+	}
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		closeFiles()
+		release()
+		return nil, err
+	}
+
+	var stderr = limits.Buffer{Max: eb.limits.Output, OnExceed: cmd.killGroup}
+	cmd.Stderr = &stderr
+
+	if err := cmd.start(); err != nil {
+		closeFiles()
+		release()
+		return nil, err
+	}
+
+	var s = Stream{
+		events: make(chan []byte),
+	}
+
+	go func() {
+		defer close(s.events)
+		defer release()
+		defer closeFiles()
+
+		var (
+			r       = bufio.NewReader(stdout)
+			readErr error
+		)
+		for {
+			line, err := readLine(r, eb.limits.Output)
+			if line = bytes.TrimSpace(line); 0 < len(line) {
+				select {
+				case s.events <- line:
+				case <-ctx.Done():
+				}
+			}
+
+			if err != nil {
+				if !errors.Is(err, io.EOF) {
+					readErr = err
+					cmd.killGroup()
+				}
+
+				// drain the pipe so the process isn't blocked writing to it
+				io.Copy(io.Discard, r)
+				break
+			}
+		}
+
+		var err = cmd.exitErr(cmd.wait(), stderr.Bytes())
+		switch {
+		case readErr != nil:
+			err = readErr
+		case stderr.Exceeded():
+			err = fmt.Errorf(
+				"resolver wrote more than %d bytes: %w",
+				eb.limits.Output, limits.ErrOutputTooLarge,
+			)
+		case err != nil:
+			var exitErr *exitError
+			if errors.As(err, &exitErr) {
+				if errs, _ := readErrorFile(errFile); 0 < len(errs) {
+					exitErr.errs = errs
+				}
+			}
+		}
+
+		// there's nobody left to tell once the subscription is over
+		if err != nil && ctx.Err() == nil {
+			s.err = describeErr(ctx, err)
+		}
+	}()
+
+	return &s, nil
+}
@@ -0,0 +1,153 @@
+package resolver
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/raphaelreyna/graphqld/internal/config"
+	"github.com/raphaelreyna/graphqld/internal/middleware"
+)
+
+// publishHeader lists the topics a resolvers output is published to.
+const publishHeader = "X-Graphqld-Publish"
+
+type topicBackend struct {
+	topic string
+
+	// filter is run for each event if set; its output is delivered instead of the event
+	filter  *execBackend
+	timeout time.Duration
+}
+
+// NewTopicBackend returns a Backend that streams the events published to tc.Name,
+// passing each one through the filter executable if there is one. Runs of the filter are recorded to or replayed from t, if it's not nil.
+func NewTopicBackend(tc config.TopicConf, fc config.FieldConf, t *Tape, c *config.GraphConf) (Backend, error) {
+	if tc.Name == "" {
+		return nil, errors.New("NewTopicBackend:: missing topic name")
+	}
+
+	var tb = topicBackend{
+		topic:   tc.Name,
+		timeout: c.ResolverTimeout,
+	}
+
+	if fc.Timeout != nil {
+		tb.timeout = *fc.Timeout
+	}
+
+	if tc.Filter != "" {
+		var path = tc.Filter
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(c.DocumentRoot, path)
+		}
+
+		tb.filter = &execBackend{
+			path:    path,
+			wd:      c.ResolverDir,
+			user:    c.User,
+			sandbox: c.Sandbox,
+			limits:  c.Limits.Merge(fc.Limits),
+			tape:    t,
+		}
+	}
+
+	return &tb, nil
+}
+
+func (tb *topicBackend) String() string {
+	if tb.filter != nil {
+		return "topic:" + tb.topic + " | " + tb.filter.path
+	}
+
+	return "topic:" + tb.topic
+}
+
+func (tb *topicBackend) run(ctx context.Context, inv *invocation) (*output, error) {
+	return nil, ErrNotStreamed
+}
+
+func (tb *topicBackend) stream(ctx context.Context, inv *invocation, describeErr errFunc) (*Stream, error) {
+	events, err := middleware.Subscribe(ctx, tb.topic)
+	if err != nil {
+		return nil, err
+	}
+
+	var s = Stream{
+		events: make(chan []byte),
+	}
+
+	go func() {
+		defer close(s.events)
+
+		for payload := range events {
+			event, err := tb.shape(ctx, inv, payload)
+			if err != nil {
+				// there's nobody left to tell once the subscription is over
+				if ctx.Err() == nil {
+					s.err = describeErr(ctx, err)
+				}
+				return
+			}
+
+			if len(event) == 0 {
+				continue
+			}
+
+			select {
+			case s.events <- event:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return &s, nil
+}
+
+// shape returns the event to deliver for payload, or nil if it isn't to be delivered.
+// The filter gets the payload on stdin and the subscriptions arguments like any resolver;
+// an empty output means the event is skipped.
+func (tb *topicBackend) shape(ctx context.Context, inv *invocation, payload []byte) ([]byte, error) {
+	if tb.filter == nil {
+		return payload, nil
+	}
+
+	if tb.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, tb.timeout)
+		defer cancel()
+	}
+
+	var finv = *inv
+	finv.params.Source = json.RawMessage(payload)
+	if !json.Valid(payload) {
+		// the source is always handed to resolvers as JSON
+		data, err := json.Marshal(string(payload))
+		if err != nil {
+			return nil, err
+		}
+		finv.params.Source = json.RawMessage(data)
+	}
+
+	release, err := middleware.AcquireResolverSlot(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	out, err := tb.filter.run(ctx, &finv)
+	if err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return nil, fmt.Errorf("filter %s timed out after %s", tb.filter.path, tb.timeout)
+		}
+
+		return nil, err
+	}
+
+	return bytes.TrimSpace(out.body), nil
+}
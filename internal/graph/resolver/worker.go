@@ -0,0 +1,414 @@
+package resolver
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+
+	"github.com/raphaelreyna/graphqld/internal/config"
+	"github.com/raphaelreyna/graphqld/internal/limits"
+	"github.com/raphaelreyna/graphqld/internal/middleware"
+	"github.com/raphaelreyna/graphqld/internal/sandbox"
+	"github.com/rs/zerolog/log"
+)
+
+// WorkerFlag is passed to an executable when it is started as a long-lived worker.
+const WorkerFlag = "--graphqld-worker"
+
+var ErrWorkerPoolClosed = errors.New("worker pool closed")
+
+// workerRequest is written to a workers stdin as a single line of JSON.
+type workerRequest struct {
+	ID      uint64                 `json:"id"`
+	Object  string                 `json:"object"`
+	Field   string                 `json:"field"`
+	Args    map[string]interface{} `json:"args"`
+	Source  interface{}            `json:"source"`
+	Env     map[string]string      `json:"env"`
+	Context json.RawMessage        `json:"context,omitempty"`
+	Info    *resolveInfo           `json:"info"`
+}
+
+// workerResponse is read from a workers stdout as a single line of JSON.
+type workerResponse struct {
+	ID      uint64              `json:"id"`
+	Data    json.RawMessage     `json:"data"`
+	Error   json.RawMessage     `json:"error"`
+	Errors  json.RawMessage     `json:"errors"`
+	Headers map[string][]string `json:"headers"`
+}
+
+// WorkerPool manages a set of long-lived resolver processes for a single
+// executable, started lazily and replaced when they crash or have served
+// their maximum number of requests.
+type WorkerPool struct {
+	path, wd string
+	conf     config.WorkerConf
+	user     *config.User
+	sandbox  *sandbox.Profile
+	limits   config.Limits
+
+	idle  chan *worker
+	slots chan struct{}
+
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	sync.Mutex
+	nextID uint64
+}
+
+// NewWorkerPool returns a pool of workers running the executable at path, each with the resource limits l.
+func NewWorkerPool(path, wd string, wc config.WorkerConf, l config.Limits, c *config.GraphConf) *WorkerPool {
+	var p = WorkerPool{
+		path:    path,
+		wd:      wd,
+		conf:    wc,
+		user:    c.User,
+		sandbox: c.Sandbox,
+		limits:  l,
+		idle:    make(chan *worker, wc.PoolSize),
+		slots:   make(chan struct{}, wc.PoolSize),
+	}
+
+	p.ctx, p.cancel = context.WithCancel(context.Background())
+
+	return &p
+}
+
+// Close terminates all idle workers; busy workers are terminated
+// once they finish their current request.
+func (p *WorkerPool) Close() error {
+	p.cancel()
+
+	for {
+		select {
+		case w := <-p.idle:
+			p.retire(w)
+		default:
+			return nil
+		}
+	}
+}
+
+func (p *WorkerPool) String() string {
+	return p.path
+}
+
+func (p *WorkerPool) run(ctx context.Context, inv *invocation) (*output, error) {
+	var req = workerRequest{
+		Object: inv.objName,
+		Field:  inv.fieldName,
+		Args:   inv.params.Args,
+		Source: inv.params.Source,
+		Env:    make(map[string]string),
+		Info:   inv.info(),
+	}
+
+	for _, kv := range middleware.GetEnv(ctx) {
+		if idx := strings.IndexByte(kv, '='); idx != -1 {
+			req.Env[kv[:idx]] = kv[idx+1:]
+		}
+	}
+	req.Env["SCRIPT_NAME"] = filepath.Base(p.path)
+	req.Env["SCRIPT_FILENAME"] = p.path
+
+	if ctxFile := middleware.GetCtxFile(ctx); ctxFile != nil {
+		data, err := ioutil.ReadFile(ctxFile.Name())
+		if err != nil {
+			return nil, fmt.Errorf("unable to read context file: %w", err)
+		}
+
+		if 0 < len(data) {
+			req.Context = json.RawMessage(data)
+		}
+	}
+
+	resp, err := p.do(ctx, &req)
+	if err != nil {
+		return nil, err
+	}
+
+	if errs := errorsFromJSON(resp.Error); 0 < len(errs) {
+		return nil, errs
+	}
+
+	return &output{
+		header: http.Header(resp.Headers),
+		body:   dataBody(resp.Data),
+		errs:   errorsFromJSON(resp.Errors),
+	}, nil
+}
+
+func (p *WorkerPool) do(ctx context.Context, req *workerRequest) (*workerResponse, error) {
+	w, err := p.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	p.Lock()
+	p.nextID++
+	req.ID = p.nextID
+	p.Unlock()
+
+	resp, err := w.do(ctx, req)
+	p.release(w)
+
+	return resp, err
+}
+
+func (p *WorkerPool) acquire(ctx context.Context) (*worker, error) {
+	if p.ctx.Err() != nil {
+		return nil, ErrWorkerPoolClosed
+	}
+
+	select {
+	case w := <-p.idle:
+		return w, nil
+	default:
+	}
+
+	select {
+	case w := <-p.idle:
+		return w, nil
+	case p.slots <- struct{}{}:
+		w, err := p.spawn()
+		if err != nil {
+			<-p.slots
+			return nil, err
+		}
+		return w, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-p.ctx.Done():
+		return nil, ErrWorkerPoolClosed
+	}
+}
+
+func (p *WorkerPool) release(w *worker) {
+	var (
+		closed  = p.ctx.Err() != nil
+		spent   = 0 < p.conf.MaxRequests && p.conf.MaxRequests <= w.requests
+		crashed = w.err != nil
+	)
+
+	if closed || spent || crashed {
+		p.retire(w)
+		return
+	}
+
+	p.idle <- w
+}
+
+func (p *WorkerPool) retire(w *worker) {
+	w.stop()
+	<-p.slots
+}
+
+func (p *WorkerPool) spawn() (*worker, error) {
+	// workers aren't bound to the pool so that closing it lets busy ones finish their request; they're stopped once retired
+	ctx, cancel := context.WithCancel(context.Background())
+
+	var cmd = newCommand(ctx, p.path, WorkerFlag)
+	cmd.limits = p.limits
+	cmd.sandbox = p.sandbox
+
+	cmd.Env = []string{
+		"SCRIPT_NAME=" + filepath.Base(p.path),
+		"SCRIPT_FILENAME=" + p.path,
+		"PATH=" + os.Getenv("PATH"),
+	}
+
+	if user := p.user; user != nil {
+		cmd.SysProcAttr.Credential = &syscall.Credential{
+			Uid: user.Uid,
+			Gid: user.Gid,
+		}
+
+		cmd.Env = append(cmd.Env,
+			"USER="+user.Name,
+			"USERNAME="+user.Name,
+			"LOGNAME="+user.Name,
+		)
+
+		if user.HomeDir != "" {
+			cmd.Env = append(cmd.Env, "HOME="+user.HomeDir)
+		}
+	}
+
+	if p.wd != "" {
+		cmd.Dir = p.wd
+	}
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	if err := cmd.start(); err != nil {
+		cancel()
+		return nil, fmt.Errorf("unable to start worker %s: %w", p.path, err)
+	}
+
+	var w = worker{
+		cmd:     cmd,
+		cancel:  cancel,
+		stdin:   stdin,
+		stdout:  bufio.NewReader(stdout),
+		maxLine: p.limits.Output,
+	}
+
+	go func() {
+		var (
+			scanner = bufio.NewScanner(stderr)
+			logger  = log.With().
+				Str("resolver", p.path).
+				Int("pid", cmd.Process.Pid).
+				Logger()
+		)
+
+		for scanner.Scan() {
+			logger.Warn().Msg(scanner.Text())
+		}
+
+		if err := cmd.wait(); err != nil && ctx.Err() == nil {
+			logger.Warn().Err(err).
+				Msg("worker exited")
+		}
+	}()
+
+	log.Info().
+		Str("resolver", p.path).
+		Int("pid", cmd.Process.Pid).
+		Msg("started worker")
+
+	return &w, nil
+}
+
+type worker struct {
+	cmd    *command
+	cancel context.CancelFunc
+
+	stdin  io.WriteCloser
+	stdout *bufio.Reader
+
+	// maxLine is the longest response the worker may write; 0 means no limit.
+	maxLine int64
+
+	requests int
+
+	// err is set once the worker can no longer be used.
+	err error
+}
+
+func (w *worker) do(ctx context.Context, req *workerRequest) (*workerResponse, error) {
+	w.requests++
+
+	data, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+
+	type result struct {
+		resp *workerResponse
+		err  error
+	}
+
+	var done = make(chan result, 1)
+	go func() {
+		var resp workerResponse
+
+		if _, err := w.stdin.Write(append(data, '\n')); err != nil {
+			done <- result{err: err}
+			return
+		}
+
+		line, err := readLine(w.stdout, w.maxLine)
+		if err != nil {
+			done <- result{err: err}
+			return
+		}
+
+		if err := json.Unmarshal(line, &resp); err != nil {
+			done <- result{err: fmt.Errorf(
+				"invalid response from worker: %w", err,
+			)}
+			return
+		}
+
+		if resp.ID != req.ID {
+			done <- result{err: fmt.Errorf(
+				"worker responded to request %d, expected %d",
+				resp.ID, req.ID,
+			)}
+			return
+		}
+
+		done <- result{resp: &resp}
+	}()
+
+	select {
+	case r := <-done:
+		if r.err != nil {
+			w.err = r.err
+			if errors.Is(r.err, io.EOF) || errors.Is(r.err, os.ErrClosed) || errors.Is(r.err, syscall.EPIPE) {
+				w.err = fmt.Errorf("worker %s exited unexpectedly", w.cmd.argv[0])
+			}
+		}
+		return r.resp, w.err
+	case <-ctx.Done():
+		// the worker is mid request and can't be reused
+		w.err = ctx.Err()
+		w.stop()
+		<-done
+		return nil, ctx.Err()
+	}
+}
+
+func (w *worker) stop() {
+	w.stdin.Close()
+	w.cancel()
+}
+
+// readLine reads a single line from r, failing if it is longer than max bytes.
+func readLine(r *bufio.Reader, max int64) ([]byte, error) {
+	var line []byte
+	for {
+		chunk, err := r.ReadSlice('\n')
+		line = append(line, chunk...)
+
+		if 0 < max && max < int64(len(line)) {
+			return nil, fmt.Errorf(
+				"worker response longer than %d bytes: %w",
+				max, limits.ErrOutputTooLarge,
+			)
+		}
+
+		if err != bufio.ErrBufferFull {
+			return line, err
+		}
+	}
+}
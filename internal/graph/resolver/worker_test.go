@@ -0,0 +1,131 @@
+package resolver
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/raphaelreyna/graphqld/internal/config"
+)
+
+// workerScript answers each request with its own pid, taking a while to answer for the slow field.
+const workerScript = `#!/bin/sh
+[ "$1" = --graphqld-worker ] || { echo "started without $1" >&2; exit 1; }
+while read -r line; do
+	id=$(echo "$line" | sed 's/^{"id":\([0-9]*\).*/\1/')
+	case "$line" in
+	*'"field":"slow"'*) sleep 0.3 ;;
+	esac
+	echo "{\"id\": $id, \"data\": $$}"
+done
+`
+
+func newTestWorkerPool(t *testing.T, wc config.WorkerConf) *WorkerPool {
+	var (
+		dir    = t.TempDir()
+		script = filepath.Join(dir, "worker")
+	)
+
+	if err := ioutil.WriteFile(script, []byte(workerScript), 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	return NewWorkerPool(script, dir, wc, config.Limits{}, &config.GraphConf{})
+}
+
+// workerPid sends a request for field to p, returning the pid of the worker that answered it.
+func workerPid(t *testing.T, p *WorkerPool, field string) int {
+	resp, err := p.do(context.Background(), &workerRequest{Field: field})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pid, err := strconv.Atoi(string(resp.Data))
+	if err != nil {
+		t.Fatalf("unexpected response %q: %v", resp.Data, err)
+	}
+
+	return pid
+}
+
+// waitExited waits for the process pid to be gone.
+func waitExited(t *testing.T, pid int) {
+	var deadline = time.Now().Add(5 * time.Second)
+	for syscall.Kill(pid, 0) == nil {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected worker %d to exit", pid)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestWorkerPoolRecycles(t *testing.T) {
+	var p = newTestWorkerPool(t, config.WorkerConf{PoolSize: 1, MaxRequests: 2})
+	defer p.Close()
+
+	var first = workerPid(t, p, "a")
+	if first <= 0 {
+		t.Fatalf("expected a worker to be spawned, got pid %d", first)
+	}
+
+	if pid := workerPid(t, p, "a"); pid != first {
+		t.Fatalf("expected worker %d to be reused, got %d", first, pid)
+	}
+
+	var second = workerPid(t, p, "a")
+	if second == first {
+		t.Fatalf("expected worker %d to be replaced after serving MaxRequests", first)
+	}
+	waitExited(t, first)
+
+	if pid := workerPid(t, p, "a"); pid != second {
+		t.Fatalf("expected worker %d to be reused, got %d", second, pid)
+	}
+}
+
+func TestWorkerPoolClose(t *testing.T) {
+	var (
+		p    = newTestWorkerPool(t, config.WorkerConf{PoolSize: 2})
+		busy = make(chan int)
+	)
+
+	go func() {
+		resp, err := p.do(context.Background(), &workerRequest{Field: "slow"})
+		if err != nil {
+			t.Error(err)
+			busy <- 0
+			return
+		}
+
+		pid, _ := strconv.Atoi(string(resp.Data))
+		busy <- pid
+	}()
+
+	// let the slow request reach its worker before another is spawned
+	time.Sleep(100 * time.Millisecond)
+	var idle = workerPid(t, p, "a")
+
+	if err := p.Close(); err != nil {
+		t.Fatal(err)
+	}
+	waitExited(t, idle)
+
+	// the busy worker finishes its request before being stopped
+	var pid = <-busy
+	if pid == 0 {
+		t.FailNow()
+	}
+	if pid == idle {
+		t.Fatalf("expected the slow request to be served by another worker than %d", idle)
+	}
+	waitExited(t, pid)
+
+	if _, err := p.do(context.Background(), &workerRequest{Field: "a"}); !errors.Is(err, ErrWorkerPoolClosed) {
+		t.Fatalf("expected %v, got %v", ErrWorkerPoolClosed, err)
+	}
+}
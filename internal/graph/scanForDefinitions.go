@@ -1,80 +1,285 @@
 package graph
 
 import (
-	"errors"
 	"fmt"
 	"io/fs"
 	"path/filepath"
+	"strings"
 
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/raphaelreyna/graphqld/internal/config"
+	"github.com/raphaelreyna/graphqld/internal/graph/resolver"
 	"github.com/raphaelreyna/graphqld/internal/scan"
 )
 
-func (g *Graph) scanForDefinitions() (definitions, resolverPaths, error) {
+// definer records where types and their members are defined, reporting any defined twice;
+// types are keyed by name, fields and enum values by Type.name.
+type definer struct {
+	g       *Graph
+	sources map[string]Diagnostic
+}
+
+// define records that key was defined at loc, returning false if it already was.
+func (d *definer) define(key, what string, loc Diagnostic) bool {
+	if other, ok := d.sources[key]; ok {
+		loc.Message = fmt.Sprintf("%s %s is already defined at %s", what, key, other.Location())
+		d.g.report(loc)
+		return false
+	}
+	d.sources[key] = loc
+
+	return true
+}
+
+// defineMembers defines the fields or values named by members of the type typeName.
+func (d *definer) defineMembers(typeName, what string, members []*ast.Name) {
+	for _, member := range members {
+		d.define(typeName+"."+member.Value, what, at(member.Loc, ""))
+	}
+}
+
+func (g *Graph) scanForDefinitions(c *config.GraphConf) (definitions, resolverFiles, error) {
 	var (
 		definitions   = make(definitions)
-		resolverPaths = make(resolverPaths)
+		resolverFiles = make(resolverFiles)
+
+		d = definer{
+			g:       g,
+			sources: make(map[string]Diagnostic),
+		}
+
+		// extensions are applied once every file has been scanned
+		extensions = make([]ast.Node, 0)
 	)
 
-	err := filepath.WalkDir(g.DocumentRoot, func(path string, d fs.DirEntry, err error) error {
-		if d.IsDir() {
+	err := filepath.WalkDir(g.DocumentRoot, func(path string, de fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if de.IsDir() {
 			return nil
 		}
 
 		var file scan.File
 		{
-			info, err := d.Info()
+			info, err := de.Info()
 			if err != nil {
 				return err
 			}
 
 			if file = scan.NewFile(path, info); file == nil {
+				// anything in an objects directory is meant to be a resolver or a schema file
+				var name = de.Name()
+				if filepath.Dir(path) != g.DocumentRoot && !strings.HasPrefix(name, ".") && !strings.HasSuffix(name, scan.TestFileSuffix) {
+					g.report(Diagnostic{
+						File:    path,
+						Message: "neither an executable resolver nor a schema file; is it missing its executable bit?",
+					})
+				}
+
 				return nil
 			}
 
-			if err := file.Scan(); err != nil {
-				if !errors.Is(err, scan.ErrNotAResolver) {
+			// mock values are only of use in mock mode
+			if _, ok := file.(*scan.MockFile); ok && !c.Mock {
+				return nil
+			}
+
+			if ef, ok := file.(*scan.ExecFile); ok {
+				// resolve-type executables don't list any fields
+				if ef.Name == resolver.ResolveTypeName {
+					files, ok := resolverFiles[ef.ObjectName]
+					if !ok {
+						files = make(map[string]scan.File)
+						resolverFiles[ef.ObjectName] = files
+					}
+
+					files[ef.Name] = file
 					return nil
 				}
 
-				return err
+				ef.Limits = c.Limits
+				ef.Sandbox = c.Sandbox
+			}
+
+			if err := file.Scan(); err != nil {
+				g.reportErr(err)
+				return nil
 			}
 		}
 
-		switch file := file.(type) {
-		case *scan.ExecFile:
-			for _, field := range file.Fields {
-				var key = fmt.Sprintf("field::%s:%s", file.ObjectName, file.Name)
+		var addResolverFile = func(objName, name string, fields []*ast.FieldDefinition) {
+			if !d.define(objName+"."+name, "field", Diagnostic{File: file.Path()}) {
+				return
+			}
+
+			for _, field := range fields {
+				var key = fmt.Sprintf("field::%s:%s", objName, name)
 
 				definitions[key] = field
 
-				paths, ok := resolverPaths[file.ObjectName]
+				files, ok := resolverFiles[objName]
 				if !ok {
-					paths = make(map[string]string)
-					resolverPaths[file.ObjectName] = paths
+					files = make(map[string]scan.File)
+					resolverFiles[objName] = files
 				}
 
-				paths[file.Name] = file.Path()
+				files[name] = file
 			}
+		}
+
+		switch file := file.(type) {
+		case *scan.ExecFile:
+			addResolverFile(file.ObjectName, file.Name, file.Fields)
+		case *scan.FastCGIFile:
+			addResolverFile(file.ObjectName, file.Name, file.Fields)
+		case *scan.HTTPFile:
+			addResolverFile(file.ObjectName, file.Name, file.Fields)
+		case *scan.MockFile:
+			definitions[fmt.Sprintf("mock::%s:%s", file.ObjectName, file.Name)] = file
 		case *scan.GraphqlFile:
+			// defineType defines a type along with its fields or values
+			var defineType = func(key string, name *ast.Name, def interface{}, what string, members []*ast.Name) {
+				if !d.define(name.Value, "type", at(name.Loc, "")) {
+					return
+				}
+				d.defineMembers(name.Value, what, members)
+
+				definitions[key+"::"+name.Value] = def
+			}
+
 			for _, obj := range file.Objects {
-				definitions["object::"+obj.Name.Value] = obj
+				defineType("object", obj.Name, obj, "field", fieldNames(obj.Fields))
 			}
 
 			for _, input := range file.Inputs {
-				definitions["input::"+input.Name.Value] = input
+				defineType("input", input.Name, input, "field", inputFieldNames(input.Fields))
 			}
 
 			for _, enum := range file.Enums {
-				definitions["enum::"+enum.Name.Value] = enum
+				defineType("enum", enum.Name, enum, "value", enumValueNames(enum.Values))
 			}
 
 			for _, iface := range file.Interfaces {
-				definitions["iface::"+iface.Name.Value] = iface
+				defineType("iface", iface.Name, iface, "field", fieldNames(iface.Fields))
+			}
+
+			for _, union := range file.Unions {
+				defineType("union", union.Name, union, "", nil)
+			}
+
+			for _, scalar := range file.Scalars {
+				defineType("scalar", scalar.Name, scalar, "", nil)
 			}
+
+			extensions = append(extensions, file.Extensions...)
 		}
 
 		return nil
 	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for _, ext := range extensions {
+		d.extend(definitions, ext)
+	}
+
+	return definitions, resolverFiles, nil
+}
+
+// extend adds the fields, values, interfaces or members of the extension ext to the type it extends.
+// The root objects may be extended without being defined in a schema file; their new fields are defined on their own.
+func (d *definer) extend(defs definitions, ext ast.Node) {
+	var undefined = func(kind string, name *ast.Name) {
+		d.g.report(at(name.Loc, "extension of undefined %s %s", kind, name.Value))
+	}
+
+	switch x := ext.(type) {
+	case *ast.ObjectDefinition:
+		var name = x.Name.Value
+
+		base, ok := defs["object::"+name].(*ast.ObjectDefinition)
+		if !ok {
+			if name != "Query" && name != "Mutation" && name != "Subscription" {
+				undefined("type", x.Name)
+				return
+			}
+
+			for _, field := range x.Fields {
+				if d.define(name+"."+field.Name.Value, "field", at(field.Name.Loc, "")) {
+					defs[fmt.Sprintf("field::%s:%s", name, field.Name.Value)] = field
+				}
+			}
+
+			return
+		}
+
+		d.defineMembers(name, "field", fieldNames(x.Fields))
+		base.Fields = append(base.Fields, x.Fields...)
+		base.Interfaces = append(base.Interfaces, x.Interfaces...)
+	case *ast.InputObjectDefinition:
+		base, ok := defs["input::"+x.Name.Value].(*ast.InputObjectDefinition)
+		if !ok {
+			undefined("input", x.Name)
+			return
+		}
+
+		d.defineMembers(x.Name.Value, "field", inputFieldNames(x.Fields))
+		base.Fields = append(base.Fields, x.Fields...)
+	case *ast.EnumDefinition:
+		base, ok := defs["enum::"+x.Name.Value].(*ast.EnumDefinition)
+		if !ok {
+			undefined("enum", x.Name)
+			return
+		}
+
+		d.defineMembers(x.Name.Value, "value", enumValueNames(x.Values))
+		base.Values = append(base.Values, x.Values...)
+	case *ast.InterfaceDefinition:
+		base, ok := defs["iface::"+x.Name.Value].(*ast.InterfaceDefinition)
+		if !ok {
+			undefined("interface", x.Name)
+			return
+		}
+
+		d.defineMembers(x.Name.Value, "field", fieldNames(x.Fields))
+		base.Fields = append(base.Fields, x.Fields...)
+	case *ast.UnionDefinition:
+		base, ok := defs["union::"+x.Name.Value].(*ast.UnionDefinition)
+		if !ok {
+			undefined("union", x.Name)
+			return
+		}
+
+		base.Types = append(base.Types, x.Types...)
+	}
+}
+
+func fieldNames(fields []*ast.FieldDefinition) []*ast.Name {
+	var names = make([]*ast.Name, len(fields))
+	for idx, f := range fields {
+		names[idx] = f.Name
+	}
+
+	return names
+}
+
+func inputFieldNames(fields []*ast.InputValueDefinition) []*ast.Name {
+	var names = make([]*ast.Name, len(fields))
+	for idx, f := range fields {
+		names[idx] = f.Name
+	}
+
+	return names
+}
+
+func enumValueNames(values []*ast.EnumValueDefinition) []*ast.Name {
+	var names = make([]*ast.Name, len(values))
+	for idx, v := range values {
+		names[idx] = v.Name
+	}
 
-	return definitions, resolverPaths, err
+	return names
 }
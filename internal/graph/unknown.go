@@ -11,6 +11,9 @@ type Unknown struct {
 	ReferencedType ast.Type
 
 	Referencer interface{}
+
+	// Loc is where the type is referenced
+	Loc *ast.Location
 }
 
 func (u *Unknown) Name() string {
@@ -74,8 +77,9 @@ func NewType(t ast.Type, referencer interface{}) (graphql.Type, *Unknown) {
 
 			u = &Unknown{
 				name:           x.Name.Value,
-				ReferencedType: x,
+				ReferencedType: t,
 				Referencer:     referencer,
+				Loc:            x.Loc,
 			}
 
 			return u
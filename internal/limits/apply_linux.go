@@ -0,0 +1,117 @@
+package limits
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/raphaelreyna/graphqld/internal/config"
+	"golang.org/x/sys/unix"
+)
+
+// Wrap rewrites cmd so that the resource limits in l are set on it before its executable runs;
+// cmd is started as graphqld in a helper mode which sets them on itself and then execs the executable.
+// It must be called last, once cmd is otherwise ready to be started (and sandboxed),
+// and does nothing if l doesn't limit any resource.
+func Wrap(cmd *exec.Cmd, l config.Limits) error {
+	if !limitsResources(l) {
+		return nil
+	}
+
+	if err := Check(l); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(&l)
+	if err != nil {
+		return err
+	}
+
+	var args = []string{"graphqld", execFlag, string(data), "--", cmd.Path}
+	if 1 < len(cmd.Args) {
+		args = append(args, cmd.Args[1:]...)
+	}
+	cmd.Args = args
+	cmd.Path = "/proc/self/exe"
+
+	return nil
+}
+
+// Check reports whether this program can apply l to the processes it starts,
+// so that problems show up before any process is started.
+func Check(l config.Limits) error {
+	if limitsResources(l) && !helpers {
+		return ErrNoHelpers
+	}
+
+	return nil
+}
+
+// apply sets the resource limits in l on the calling process; they are kept across execve.
+func apply(l config.Limits) error {
+	var cpu int64
+	if 0 < l.CPU {
+		// round up so that sub-second limits don't become no limit
+		cpu = int64((l.CPU + time.Second - 1) / time.Second)
+	}
+
+	for _, rl := range []struct {
+		name     string
+		resource int
+		value    int64
+	}{
+		{"address space", unix.RLIMIT_AS, l.AddressSpace},
+		{"cpu", unix.RLIMIT_CPU, cpu},
+		{"open files", unix.RLIMIT_NOFILE, l.OpenFiles},
+		{"processes", unix.RLIMIT_NPROC, l.Processes},
+		{"file size", unix.RLIMIT_FSIZE, l.FileSize},
+	} {
+		if rl.value <= 0 {
+			continue
+		}
+
+		var lim = unix.Rlimit{
+			Cur: uint64(rl.value),
+			Max: uint64(rl.value),
+		}
+		if err := unix.Setrlimit(rl.resource, &lim); err != nil {
+			return fmt.Errorf("unable to set %s limit: %w", rl.name, err)
+		}
+	}
+
+	return nil
+}
+
+// runExec sets the resource limits it was given and execs the executable.
+func runExec(args []string) error {
+	if len(args) < 3 || args[1] != "--" {
+		return fmt.Errorf("invalid arguments: %s", strings.Join(args, " "))
+	}
+
+	var (
+		l    config.Limits
+		argv = args[2:]
+		path = argv[0]
+	)
+	if err := json.Unmarshal([]byte(args[0]), &l); err != nil {
+		return fmt.Errorf("invalid limits: %w", err)
+	}
+
+	if !strings.Contains(path, "/") {
+		var err error
+		if path, err = exec.LookPath(path); err != nil {
+			return err
+		}
+	}
+
+	// everything is ready before the limits are set, so that nothing but execve runs under them
+	var env = os.Environ()
+	if err := apply(l); err != nil {
+		return err
+	}
+
+	return unix.Exec(path, argv, env)
+}
@@ -0,0 +1,32 @@
+//go:build !linux
+// +build !linux
+
+package limits
+
+import (
+	"errors"
+	"os/exec"
+
+	"github.com/raphaelreyna/graphqld/internal/config"
+)
+
+var errUnsupported = errors.New("resource limits are only supported on linux")
+
+// Wrap rewrites cmd so that the resource limits in l are set on it before its executable runs.
+// Only output limits are supported outside of Linux.
+func Wrap(cmd *exec.Cmd, l config.Limits) error {
+	return Check(l)
+}
+
+// Check reports whether this program can apply l to the processes it starts.
+func Check(l config.Limits) error {
+	if limitsResources(l) {
+		return errUnsupported
+	}
+
+	return nil
+}
+
+func runExec(args []string) error {
+	return errUnsupported
+}
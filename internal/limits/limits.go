@@ -0,0 +1,121 @@
+// Package limits applies resource limits to the processes graphqld starts
+// and caps how much of their output is read.
+//
+// A limited command is started as graphqld itself in a helper mode which sets the limits
+// before execing the actual executable, so that it never runs without them;
+// the helper is dispatched by Main, which has to run before anything else does.
+package limits
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+
+	"github.com/raphaelreyna/graphqld/internal/config"
+)
+
+// ErrNoHelpers is returned when limiting a process from a program that didn't call Main,
+// which would be re-executed instead of the helper.
+var ErrNoHelpers = errors.New("graphqld helpers aren't enabled; call graphqld.RunHelper at the start of main")
+
+// helpers is set once Main returned, meaning the program can be re-executed as the helper.
+var helpers bool
+
+// execFlag starts the helper that sets the resource limits it is given and then execs the executable.
+const execFlag = "--graphqld-limits-exec"
+
+// Main runs the helper the process was started as, if any, and exits once it's done;
+// it returns right away otherwise. Processes can only be limited by a program calling it first thing in main.
+func Main() {
+	if len(os.Args) < 2 || os.Args[1] != execFlag {
+		helpers = true
+		return
+	}
+
+	fmt.Fprintf(os.Stderr, "graphqld limits: %v\n", runExec(os.Args[2:]))
+	os.Exit(1)
+}
+
+// limitsResources reports whether l sets any limit that has to be applied to the process itself.
+func limitsResources(l config.Limits) bool {
+	return l.AddressSpace != 0 || l.CPU != 0 || l.OpenFiles != 0 || l.Processes != 0 || l.FileSize != 0
+}
+
+// ErrOutputTooLarge is returned when a process writes more than it is allowed to.
+var ErrOutputTooLarge = errors.New("output too large")
+
+// Buffer is a buffer that refuses to grow past Max bytes;
+// OnExceed is called the first time a write would make it do so.
+type Buffer struct {
+	Max      int64
+	OnExceed func()
+
+	// buf isn't embedded so that io.Copy can't go around Write through its ReadFrom method.
+	buf      bytes.Buffer
+	exceeded bool
+}
+
+func (b *Buffer) Write(p []byte) (int, error) {
+	if b.exceeded {
+		return 0, ErrOutputTooLarge
+	}
+
+	if 0 < b.Max && b.Max < int64(b.buf.Len()+len(p)) {
+		b.exceeded = true
+		if b.OnExceed != nil {
+			b.OnExceed()
+		}
+
+		return 0, ErrOutputTooLarge
+	}
+
+	return b.buf.Write(p)
+}
+
+// Bytes returns what has been written to the buffer.
+func (b *Buffer) Bytes() []byte {
+	return b.buf.Bytes()
+}
+
+// Exceeded reports whether a write was ever refused.
+func (b *Buffer) Exceeded() bool {
+	return b.exceeded
+}
+
+// Output runs cmd with l applied to it and returns its standard output,
+// killing it if it writes more than l.Output bytes to either stdout or stderr.
+func Output(cmd *exec.Cmd, l config.Limits) ([]byte, error) {
+	var (
+		kill = func() {
+			if cmd.Process != nil {
+				cmd.Process.Kill()
+			}
+		}
+		stdout = Buffer{Max: l.Output, OnExceed: kill}
+		stderr = Buffer{Max: l.Output, OnExceed: kill}
+	)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	var path = cmd.Path
+	if err := Wrap(cmd, l); err != nil {
+		return nil, err
+	}
+
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	err := cmd.Wait()
+	if stdout.Exceeded() || stderr.Exceeded() {
+		return nil, fmt.Errorf("%s wrote more than %d bytes: %w", path, l.Output, ErrOutputTooLarge)
+	}
+
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		exitErr.Stderr = stderr.Bytes()
+	}
+
+	return stdout.Bytes(), err
+}
@@ -0,0 +1,112 @@
+package limits
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/raphaelreyna/graphqld/internal/config"
+)
+
+func TestBuffer(t *testing.T) {
+	var (
+		exceeded int
+		b        = Buffer{Max: 8, OnExceed: func() { exceeded++ }}
+	)
+
+	if n, err := b.Write([]byte("12345")); n != 5 || err != nil {
+		t.Fatalf("expected 5 bytes to be written, got %d, %v", n, err)
+	}
+
+	if n, err := b.Write([]byte("678")); n != 3 || err != nil {
+		t.Fatalf("expected writing up to Max to succeed, got %d, %v", n, err)
+	}
+
+	if _, err := b.Write([]byte("9")); !errors.Is(err, ErrOutputTooLarge) {
+		t.Fatalf("expected %v, got %v", ErrOutputTooLarge, err)
+	}
+
+	if _, err := b.Write(nil); !errors.Is(err, ErrOutputTooLarge) {
+		t.Fatalf("expected every write after the cap was hit to fail, got %v", err)
+	}
+
+	if exceeded != 1 {
+		t.Errorf("expected OnExceed to be called once, got %d", exceeded)
+	}
+
+	if !b.Exceeded() {
+		t.Error("expected the buffer to report it was exceeded")
+	}
+
+	if got := string(b.Bytes()); got != "12345678" {
+		t.Errorf("expected the refused write to be left out, got %q", got)
+	}
+}
+
+func TestBufferCopy(t *testing.T) {
+	var b = Buffer{Max: 1 << 10}
+
+	if _, err := io.Copy(&b, strings.NewReader(strings.Repeat("x", 1<<12))); !errors.Is(err, ErrOutputTooLarge) {
+		t.Fatalf("expected io.Copy to stop at the cap, got %v", err)
+	}
+
+	if n := len(b.Bytes()); 1<<10 < n {
+		t.Errorf("expected at most %d bytes, got %d", 1<<10, n)
+	}
+}
+
+func TestBufferNoMax(t *testing.T) {
+	var b = Buffer{OnExceed: func() { t.Error("expected a buffer without Max never to be exceeded") }}
+
+	if _, err := io.Copy(&b, strings.NewReader(strings.Repeat("x", 1<<16))); err != nil {
+		t.Fatal(err)
+	}
+
+	if n := len(b.Bytes()); n != 1<<16 {
+		t.Errorf("expected %d bytes, got %d", 1<<16, n)
+	}
+}
+
+func TestMerge(t *testing.T) {
+	var (
+		graph = config.Limits{
+			AddressSpace: 1 << 30,
+			CPU:          time.Second,
+			OpenFiles:    64,
+			Output:       1 << 20,
+		}
+		field = config.Limits{
+			CPU:      5 * time.Second,
+			FileSize: 1 << 10,
+		}
+		want = config.Limits{
+			AddressSpace: 1 << 30,
+			CPU:          5 * time.Second,
+			OpenFiles:    64,
+			FileSize:     1 << 10,
+			Output:       1 << 20,
+		}
+	)
+
+	if got := graph.Merge(field); got != want {
+		t.Errorf("expected %+v, got %+v", want, got)
+	}
+
+	if got := graph.Merge(config.Limits{}); got != graph {
+		t.Errorf("expected merging no limits to change nothing, got %+v", got)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	// output is capped by graphqld itself, without a helper
+	if err := Check(config.Limits{Output: 1 << 20}); err != nil {
+		t.Errorf("expected output limits to need no helper, got %v", err)
+	}
+
+	// Main isn't called by the test binary
+	if err := Check(config.Limits{CPU: time.Second}); err == nil {
+		t.Error("expected limiting resources without the helper to fail")
+	}
+}
@@ -0,0 +1,52 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"sync"
+
+	"github.com/graphql-go/graphql/gqlerrors"
+)
+
+// errorList holds errors to be reported alongside a responses data.
+type errorList struct {
+	sync.Mutex
+	errs []gqlerrors.FormattedError
+}
+
+// AddErrors records errs to be reported in the response to the request ctx belongs to.
+func AddErrors(ctx context.Context, errs ...gqlerrors.FormattedError) {
+	l, ok := ctx.Value(keyErrors).(*errorList)
+	if !ok {
+		return
+	}
+
+	l.Lock()
+	l.errs = append(l.errs, errs...)
+	l.Unlock()
+}
+
+// NewOperation derives a context for executing another operation within the request ctx belongs to,
+// such as one for each event of a subscription. It records its own errors
+// and headers set by its resolvers are discarded since the response is already under way.
+func NewOperation(ctx context.Context) context.Context {
+	var header = make(http.Header)
+	ctx = context.WithValue(ctx, keyHeaderFunc, func() http.Header {
+		return header
+	})
+
+	return context.WithValue(ctx, keyErrors, &errorList{})
+}
+
+// GetErrors returns the errors recorded with AddErrors.
+func GetErrors(ctx context.Context) []gqlerrors.FormattedError {
+	l, ok := ctx.Value(keyErrors).(*errorList)
+	if !ok {
+		return nil
+	}
+
+	l.Lock()
+	defer l.Unlock()
+
+	return append([]gqlerrors.FormattedError{}, l.errs...)
+}
@@ -16,6 +16,8 @@ import (
 	"syscall"
 
 	"github.com/raphaelreyna/graphqld/internal/config"
+	"github.com/raphaelreyna/graphqld/internal/limits"
+	"github.com/raphaelreyna/graphqld/internal/sandbox"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 )
@@ -28,6 +30,10 @@ const (
 	keyEnv
 	keyCtxFile
 	keyLog
+	keySlots
+	keyErrors
+	keyBus
+	keyUploadDir
 )
 
 func GetLogger(ctx context.Context) *zerolog.Logger {
@@ -51,7 +57,8 @@ func GetWHeader(ctx context.Context) http.Header {
 }
 
 func GetRHeader(ctx context.Context) http.Header {
-	return ctx.Value(keyHeader).(http.Header)
+	header, _ := ctx.Value(keyHeader).(http.Header)
+	return header
 }
 
 func GetEnv(ctx context.Context) []string {
@@ -63,6 +70,17 @@ func GetEnv(ctx context.Context) []string {
 	return env
 }
 
+// WithUploadDir returns a copy of ctx holding dir, the directory the files uploaded with the request are in.
+func WithUploadDir(ctx context.Context, dir string) context.Context {
+	return context.WithValue(ctx, keyUploadDir, dir)
+}
+
+// GetUploadDir returns the directory the files uploaded with the request are in, if any.
+func GetUploadDir(ctx context.Context) string {
+	dir, _ := ctx.Value(keyUploadDir).(string)
+	return dir
+}
+
 func Log(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		var (
@@ -89,113 +107,179 @@ func Log(next http.Handler) http.Handler {
 	})
 }
 
-func FromGraphConf(c config.GraphConf) func(http.Handler) http.Handler {
-	return func(handler http.Handler) http.Handler {
-		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			var (
-				ctx    = r.Context()
-				env    = getEnv(r)
-				logger = GetLogger(ctx)
-			)
+// Graph holds what's shared by every request to a graph.
+type Graph struct {
+	conf config.GraphConf
 
-			logger.Info().Send()
+	slots semaphore
+	bus   *bus
+}
 
-			ctx = context.WithValue(ctx, keyEnv, env)
-			ctx = context.WithValue(ctx, keyHeaderFunc, w.Header)
+func NewGraph(c config.GraphConf) *Graph {
+	return &Graph{
+		conf:  c,
+		slots: newSemaphore(c.MaxParallelism),
+		bus:   newBus(),
+	}
+}
 
-			if cctx := c.Context; cctx != nil {
-				ctxFile, err := ioutil.TempFile(cctx.TmpDir, "")
-				if err != nil {
-					logger.Error().Err(err).
-						Msg("unable to create temporary context file")
+// Wrap returns handler serving requests to the graph with everything their resolvers use in their context.
+func (g *Graph) Wrap(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var logger = GetLogger(r.Context())
 
-					http.Error(w, err.Error(), http.StatusInternalServerError)
+		logger.Info().Send()
 
-					return
-				}
-				defer func() {
-					ctxFile.Close()
-					os.Remove(ctxFile.Name())
-				}()
-
-				var ctxData []byte
-				switch {
-				case cctx.ExecPath != "":
-					var (
-						cmd = exec.Cmd{
-							Path: cctx.ExecPath,
-							Env:  env,
-						}
-					)
-
-					if user := c.User; user != nil {
-						cmd.SysProcAttr = &syscall.SysProcAttr{
-							Credential: &syscall.Credential{
-								Uid: user.Uid,
-								Gid: user.Gid,
-							},
-						}
+		ctx, release, err := g.NewContext(r.Context(), Request{
+			Env:            Env(r),
+			Header:         r.Header,
+			ResponseHeader: w.Header(),
+		})
+		if err != nil {
+			logger.Error().Err(err).
+				Msg("unable to create request context")
 
-						cmd.Env = append(cmd.Env,
-							"USER="+user.Name,
-							"USERNAME="+user.Name,
-							"LOGNAME="+user.Name,
-						)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
 
-						if user.HomeDir != "" {
-							cmd.Env = append(cmd.Env, "HOME="+user.HomeDir)
-						}
-					}
+			return
+		}
+		defer release()
 
-					ctxData, err = cmd.Output()
-					if err != nil {
-						logger.Error().Err(err).
-							Msg("unable to create a context from the ctx handler")
+		r.Body = &limitedReaderCloser{
+			LimitedReader: io.LimitedReader{
+				R: r.Body,
+				N: g.conf.MaxBodyReadSize,
+			},
+		}
+
+		handler.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
 
-						http.Error(w, err.Error(), http.StatusInternalServerError)
+// Request is what the resolvers of a request are given of it.
+type Request struct {
+	// Env is the CGI environment of the request.
+	Env    []string
+	Header http.Header
 
-						return
-					}
-				case cctx.Context != nil:
-					var err error
+	// ResponseHeader holds the headers resolvers set on the response.
+	ResponseHeader http.Header
 
-					ctxData, err = json.Marshal(cctx.Context)
-					if err != nil {
-						logger.Error().Err(err).
-							Msg("unable to marshal config context as JSON")
+	// Context is written to the context file in place of the context configured for the graph, if it's not nil.
+	Context []byte
+}
 
-						http.Error(w, err.Error(), http.StatusInternalServerError)
+// NewContext returns a copy of ctx holding req along with everything else the resolvers of a request to the graph use.
+// The returned func removes the context file, if one was created, and must be called once the request is done.
+func (g *Graph) NewContext(ctx context.Context, req Request) (context.Context, func(), error) {
+	var (
+		c       = g.conf
+		release = func() {}
+	)
 
-						return
-					}
-				}
+	ctx = context.WithValue(ctx, keyEnv, req.Env)
+	ctx = context.WithValue(ctx, keyHeader, req.Header)
+	ctx = context.WithValue(ctx, keyHeaderFunc, func() http.Header {
+		return req.ResponseHeader
+	})
+	ctx = context.WithValue(ctx, keySlots, &slots{
+		graph:   g.slots,
+		request: newSemaphore(c.MaxRequestParallelism),
+	})
+	ctx = context.WithValue(ctx, keyErrors, &errorList{})
+	ctx = context.WithValue(ctx, keyBus, g.bus)
 
-				if _, err := ctxFile.Write(ctxData); err != nil {
-					logger.Error().Err(err).
-						Msg("unable to write context to the context file")
+	var cctx = c.Context
+	if cctx == nil && req.Context == nil {
+		return ctx, release, nil
+	}
 
-					http.Error(w, err.Error(), http.StatusInternalServerError)
+	var ctxData = req.Context
+	if ctxData == nil {
+		var err error
+		if ctxData, err = contextData(cctx, req.Env, c); err != nil {
+			return nil, nil, err
+		}
+	}
 
-					return
-				}
+	var tmpDir string
+	if cctx != nil {
+		tmpDir = cctx.TmpDir
+	}
 
-				ctx = context.WithValue(ctx, keyCtxFile, ctxFile)
+	ctxFile, err := ioutil.TempFile(tmpDir, "")
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to create temporary context file: %w", err)
+	}
+	release = func() {
+		ctxFile.Close()
+		os.Remove(ctxFile.Name())
+	}
+
+	if _, err := ctxFile.Write(ctxData); err != nil {
+		release()
+		return nil, nil, fmt.Errorf("unable to write context to the context file: %w", err)
+	}
 
+	return context.WithValue(ctx, keyCtxFile, ctxFile), release, nil
+}
+
+// contextData returns the context configured for a graph, running its context executable if it has one.
+func contextData(cctx *config.Context, env []string, c config.GraphConf) ([]byte, error) {
+	switch {
+	case cctx.ExecPath != "":
+		var (
+			cmd = exec.Cmd{
+				Path: cctx.ExecPath,
+				Env:  env,
 			}
+		)
 
-			r.Body = &limitedReaderCloser{
-				LimitedReader: io.LimitedReader{
-					R: r.Body,
-					N: c.MaxBodyReadSize,
+		if user := c.User; user != nil {
+			cmd.SysProcAttr = &syscall.SysProcAttr{
+				Credential: &syscall.Credential{
+					Uid: user.Uid,
+					Gid: user.Gid,
 				},
 			}
 
-			handler.ServeHTTP(w, r.WithContext(ctx))
-		})
+			cmd.Env = append(cmd.Env,
+				"USER="+user.Name,
+				"USERNAME="+user.Name,
+				"LOGNAME="+user.Name,
+			)
+
+			if user.HomeDir != "" {
+				cmd.Env = append(cmd.Env, "HOME="+user.HomeDir)
+			}
+		}
+
+		if c.Sandbox != nil {
+			if err := sandbox.Wrap(&cmd, c.Sandbox); err != nil {
+				return nil, fmt.Errorf("unable to create a context from the ctx handler: %w", err)
+			}
+		}
+
+		data, err := limits.Output(&cmd, c.Limits)
+		if err != nil {
+			return nil, fmt.Errorf("unable to create a context from the ctx handler: %w", err)
+		}
+
+		return data, nil
+	case cctx.Context != nil:
+		data, err := json.Marshal(cctx.Context)
+		if err != nil {
+			return nil, fmt.Errorf("unable to marshal config context as JSON: %w", err)
+		}
+
+		return data, nil
 	}
+
+	return nil, nil
 }
 
-func getEnv(r *http.Request) []string {
+// Env returns the CGI environment of r, which resolvers are run with.
+func Env(r *http.Request) []string {
 	var (
 		upperCaseAndUnderscore = func(r rune) rune {
 			switch {
@@ -0,0 +1,83 @@
+package middleware
+
+import (
+	"context"
+)
+
+// semaphore bounds how many resolvers may run at once; a nil semaphore is unbounded.
+type semaphore chan struct{}
+
+func newSemaphore(n int) semaphore {
+	if n <= 0 {
+		return nil
+	}
+
+	return make(semaphore, n)
+}
+
+func (s semaphore) acquire(ctx context.Context) error {
+	if s == nil {
+		return nil
+	}
+
+	select {
+	case s <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (s semaphore) release() {
+	if s != nil {
+		<-s
+	}
+}
+
+// slots holds the semaphores of the graph and the request a context belongs to.
+type slots struct {
+	graph, request semaphore
+}
+
+// AcquireResolverSlot blocks until both the request and the graph ctx belongs to are allowed
+// to run another resolver, or until ctx is done.
+// The returned func must be called once the resolver has finished.
+func AcquireResolverSlot(ctx context.Context) (func(), error) {
+	s, ok := ctx.Value(keySlots).(*slots)
+	if !ok {
+		return func() {}, nil
+	}
+
+	// always acquire the request slot first so requests don't hold on to
+	// graph slots while waiting on their own cap
+	if err := s.request.acquire(ctx); err != nil {
+		return nil, err
+	}
+
+	if err := s.graph.acquire(ctx); err != nil {
+		s.request.release()
+		return nil, err
+	}
+
+	return func() {
+		s.graph.release()
+		s.request.release()
+	}, nil
+}
+
+// AcquireStreamSlot blocks until the graph ctx belongs to is allowed to run another resolver, or until ctx is done.
+// Subscription resolvers hold their slot for as long as they run, which outlives the request they were started by,
+// so they only count against the graph.
+// The returned func must be called once the resolver has finished.
+func AcquireStreamSlot(ctx context.Context) (func(), error) {
+	s, ok := ctx.Value(keySlots).(*slots)
+	if !ok {
+		return func() {}, nil
+	}
+
+	if err := s.graph.acquire(ctx); err != nil {
+		return nil, err
+	}
+
+	return s.graph.release, nil
+}
@@ -0,0 +1,83 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// subscriberBacklog is how many events a subscriber may fall behind before missing some.
+const subscriberBacklog = 64
+
+// ErrNoBus is returned when subscribing outside of a request to a graph.
+var ErrNoBus = errors.New("no publish/subscribe bus for this request")
+
+// bus delivers the payloads published to a topic to its subscribers.
+type bus struct {
+	sync.Mutex
+	topics map[string]map[chan []byte]struct{}
+}
+
+func newBus() *bus {
+	return &bus{
+		topics: make(map[string]map[chan []byte]struct{}),
+	}
+}
+
+// Publish delivers payload to the current subscribers of topic within the graph ctx belongs to,
+// returning how many of them missed it because they had fallen too far behind.
+func Publish(ctx context.Context, topic string, payload []byte) int {
+	b, ok := ctx.Value(keyBus).(*bus)
+	if !ok {
+		return 0
+	}
+
+	b.Lock()
+	defer b.Unlock()
+
+	var missed int
+	for ch := range b.topics[topic] {
+		select {
+		case ch <- payload:
+		default:
+			missed++
+		}
+	}
+
+	return missed
+}
+
+// Subscribe returns the payloads published to topic within the graph ctx belongs to
+// from now on, until ctx is done.
+func Subscribe(ctx context.Context, topic string) (<-chan []byte, error) {
+	b, ok := ctx.Value(keyBus).(*bus)
+	if !ok {
+		return nil, ErrNoBus
+	}
+
+	var ch = make(chan []byte, subscriberBacklog)
+
+	b.Lock()
+	subs, ok := b.topics[topic]
+	if !ok {
+		subs = make(map[chan []byte]struct{})
+		b.topics[topic] = subs
+	}
+	subs[ch] = struct{}{}
+	b.Unlock()
+
+	go func() {
+		<-ctx.Done()
+
+		b.Lock()
+		delete(subs, ch)
+		if len(subs) == 0 {
+			delete(b.topics, topic)
+		}
+		b.Unlock()
+
+		close(ch)
+	}()
+
+	return ch, nil
+}
@@ -0,0 +1,92 @@
+// Package sandbox runs processes in their own Linux namespaces.
+//
+// A sandboxed command is started as graphqld itself in a helper mode which sets up
+// the sandbox before running the actual executable; the helper is dispatched by Main,
+// which has to run before anything else does.
+package sandbox
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// ErrNoHelpers is returned when sandboxing a process from a program that didn't call Main,
+// which would be re-executed instead of the helper.
+var ErrNoHelpers = errors.New("graphqld helpers aren't enabled; call graphqld.RunHelper at the start of main")
+
+// helpers is set once Main returned, meaning the program can be re-executed as the helper.
+var helpers bool
+
+const (
+	// initFlag starts the helper that sets up mounts and then runs the executable as its child.
+	initFlag = "--graphqld-sandbox-init"
+
+	// execFlag starts the helper that installs the seccomp filter and then execs the executable.
+	execFlag = "--graphqld-sandbox-exec"
+)
+
+// Profile describes the sandbox a process is run in.
+type Profile struct {
+	// Root is bound read-only into the sandbox; it is the document root of the graph.
+	Root string `json:"root"`
+
+	// Binds are other paths bound read-only into the sandbox,
+	// such as an executable living outside of Root.
+	Binds []string `json:"binds,omitempty"`
+
+	// Network lets the process share the hosts network;
+	// without it the process only gets its own loopback interface.
+	Network bool `json:"network"`
+
+	// Seccomp is the allowlist of system calls the process may make;
+	// any other fails with EPERM. No filter is installed if it's empty.
+	Seccomp []string `json:"seccomp"`
+}
+
+// Check reports whether this program can run processes in the sandbox described by p,
+// so that problems show up before any process is started.
+func Check(p *Profile) error {
+	if err := p.Validate(); err != nil {
+		return err
+	}
+
+	if !helpers {
+		return ErrNoHelpers
+	}
+
+	return nil
+}
+
+// Main runs the helper the process was started as, if any, and exits once it's done;
+// it returns right away otherwise. Processes can only be sandboxed by a program calling it first thing in main.
+func Main() {
+	if len(os.Args) < 2 {
+		helpers = true
+		return
+	}
+
+	var err error
+	switch os.Args[1] {
+	case initFlag:
+		err = runInit(os.Args[2:])
+	case execFlag:
+		err = runExec(os.Args[2:])
+	default:
+		helpers = true
+		return
+	}
+
+	fmt.Fprintf(os.Stderr, "graphqld sandbox: %v\n", err)
+	os.Exit(1)
+}
+
+// splitArgs splits helper arguments into the n leading ones and the command after the "--" following them.
+func splitArgs(args []string, n int) ([]string, []string, error) {
+	if len(args) < n+2 || args[n] != "--" {
+		return nil, nil, fmt.Errorf("invalid arguments: %s", strings.Join(args, " "))
+	}
+
+	return args[:n], args[n+1:], nil
+}
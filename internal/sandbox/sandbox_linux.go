@@ -0,0 +1,421 @@
+package sandbox
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"syscall"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// seccomp constants missing from golang.org/x/sys/unix.
+const (
+	seccompRetKillProcess = 0x80000000
+	seccompRetErrno       = 0x00050000
+	seccompRetAllow       = 0x7fff0000
+
+	// offsets into struct seccomp_data
+	seccompDataNr   = 0
+	seccompDataArch = 4
+)
+
+// statfs flags that can't be cleared by a remount from within a user namespace.
+var lockedFlags = []struct {
+	st, ms uintptr
+}{
+	{0x0002, unix.MS_NOSUID},     // ST_NOSUID
+	{0x0004, unix.MS_NODEV},      // ST_NODEV
+	{0x0008, unix.MS_NOEXEC},     // ST_NOEXEC
+	{0x0400, unix.MS_NOATIME},    // ST_NOATIME
+	{0x0800, unix.MS_NODIRATIME}, // ST_NODIRATIME
+	{0x1000, unix.MS_RELATIME},   // ST_RELATIME
+}
+
+// Validate reports whether p can be used on this system.
+func (p *Profile) Validate() error {
+	if 0 < len(p.Seccomp) && syscallNumbers == nil {
+		return fmt.Errorf("seccomp allowlists aren't supported on %s", runtime.GOARCH)
+	}
+
+	for _, name := range p.Seccomp {
+		if _, ok := syscallNumbers[name]; !ok {
+			return fmt.Errorf("unknown system call %q in seccomp allowlist", name)
+		}
+	}
+
+	return nil
+}
+
+// Wrap rewrites cmd so that it runs in the sandbox described by p.
+// It must be called once cmd is otherwise ready to be started;
+// the credentials cmd would run with are used as the sandboxes root user.
+func Wrap(cmd *exec.Cmd, p *Profile) error {
+	if err := Check(p); err != nil {
+		return err
+	}
+
+	// the executable has to survive /tmp being replaced even if it lives outside of the root
+	var wp = *p
+	{
+		var path = cmd.Path
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(cmd.Dir, path)
+		}
+
+		path, err := filepath.Abs(path)
+		if err != nil {
+			return err
+		}
+
+		if rel, err := filepath.Rel(p.Root, path); p.Root == "" || err != nil || strings.HasPrefix(rel, "..") {
+			wp.Binds = append(append([]string{}, p.Binds...), path)
+		}
+	}
+
+	profile, err := json.Marshal(&wp)
+	if err != nil {
+		return err
+	}
+
+	// the helper needs to know which extra files it was given to hand them down
+	var fds []string
+	for idx, f := range cmd.ExtraFiles {
+		if f != nil {
+			fds = append(fds, strconv.Itoa(3+idx))
+		}
+	}
+
+	var args = []string{"graphqld", initFlag, string(profile), strings.Join(fds, ","), "--", cmd.Path}
+	if 1 < len(cmd.Args) {
+		args = append(args, cmd.Args[1:]...)
+	}
+	cmd.Args = args
+	cmd.Path = "/proc/self/exe"
+
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	var attr = cmd.SysProcAttr
+
+	// setuid would fail in the new user namespace; the user is mapped to its root instead
+	uid, gid := os.Getuid(), os.Getgid()
+	if cred := attr.Credential; cred != nil {
+		uid, gid = int(cred.Uid), int(cred.Gid)
+		attr.Credential = nil
+	}
+
+	attr.Cloneflags |= syscall.CLONE_NEWUSER | syscall.CLONE_NEWNS | syscall.CLONE_NEWPID | syscall.CLONE_NEWIPC
+	if !p.Network {
+		attr.Cloneflags |= syscall.CLONE_NEWNET
+	}
+
+	attr.UidMappings = []syscall.SysProcIDMap{{ContainerID: 0, HostID: uid, Size: 1}}
+	attr.GidMappings = []syscall.SysProcIDMap{{ContainerID: 0, HostID: gid, Size: 1}}
+
+	return nil
+}
+
+// runInit is the init process of the sandbox.
+// It sets up the mounts, runs the executable through the exec helper and exits with its status.
+func runInit(args []string) error {
+	opts, argv, err := splitArgs(args, 2)
+	if err != nil {
+		return err
+	}
+
+	var p Profile
+	if err := json.Unmarshal([]byte(opts[0]), &p); err != nil {
+		return fmt.Errorf("invalid profile: %w", err)
+	}
+
+	var extraFiles []*os.File
+	if opts[1] != "" {
+		for _, s := range strings.Split(opts[1], ",") {
+			fd, err := strconv.Atoi(s)
+			if err != nil || fd < 3 {
+				return fmt.Errorf("invalid file descriptor %q", s)
+			}
+
+			for len(extraFiles) < fd-3 {
+				extraFiles = append(extraFiles, nil)
+			}
+			extraFiles = append(extraFiles, os.NewFile(uintptr(fd), ""))
+		}
+	}
+
+	if err := mount(p); err != nil {
+		return err
+	}
+
+	var cmd = exec.Command("/proc/self/exe")
+	cmd.Args = append([]string{"graphqld", execFlag, strings.Join(p.Seccomp, ","), "--"}, argv...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.ExtraFiles = extraFiles
+
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	// being pid 1, signals without a handler are ignored so they're passed on instead
+	var sigs = make(chan os.Signal, 1)
+	signal.Notify(sigs, unix.SIGTERM, unix.SIGINT, unix.SIGHUP, unix.SIGQUIT)
+	go func() {
+		for sig := range sigs {
+			cmd.Process.Signal(sig)
+		}
+	}()
+
+	// reap every orphan that gets reparented to us until the executable itself exits;
+	// anything still running is killed by the kernel once we're gone
+	for {
+		var ws unix.WaitStatus
+		pid, err := unix.Wait4(-1, &ws, 0, nil)
+		if errors.Is(err, unix.EINTR) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		if pid != cmd.Process.Pid {
+			continue
+		}
+
+		if ws.Signaled() {
+			os.Exit(128 + int(ws.Signal()))
+		}
+
+		os.Exit(ws.ExitStatus())
+	}
+}
+
+// mount makes the whole filesystem read-only and gives the sandbox its own /tmp and /dev/shm,
+// the document root and a /proc matching its pid namespace.
+func mount(p Profile) error {
+	// keep everything below from propagating back to the host
+	if err := unix.Mount("", "/", "", unix.MS_REC|unix.MS_PRIVATE, ""); err != nil {
+		return fmt.Errorf("unable to make mounts private: %w", err)
+	}
+
+	var paths = p.Binds
+	if p.Root != "" {
+		paths = append([]string{p.Root}, paths...)
+	}
+
+	// everything bound is opened before /tmp is replaced in case it lives there
+	var files = make([]*os.File, len(paths))
+	for idx, path := range paths {
+		f, err := os.OpenFile(path, unix.O_PATH, 0)
+		if err != nil {
+			return fmt.Errorf("unable to open %s: %w", path, err)
+		}
+		defer f.Close()
+
+		files[idx] = f
+	}
+
+	if err := remountAll(); err != nil {
+		return err
+	}
+
+	if err := unix.Mount("tmpfs", "/tmp", "tmpfs", unix.MS_NOSUID|unix.MS_NODEV, "mode=1777"); err != nil {
+		return fmt.Errorf("unable to mount /tmp: %w", err)
+	}
+
+	if info, err := os.Stat("/dev/shm"); err == nil && info.IsDir() {
+		if err := unix.Mount("tmpfs", "/dev/shm", "tmpfs", unix.MS_NOSUID|unix.MS_NODEV, "mode=1777"); err != nil {
+			return fmt.Errorf("unable to mount /dev/shm: %w", err)
+		}
+	}
+
+	for idx, path := range paths {
+		if err := bindReadOnly(files[idx], path); err != nil {
+			return fmt.Errorf("unable to bind %s: %w", path, err)
+		}
+	}
+
+	if err := unix.Mount("proc", "/proc", "proc", unix.MS_NOSUID|unix.MS_NODEV|unix.MS_NOEXEC, ""); err != nil {
+		return fmt.Errorf("unable to mount /proc: %w", err)
+	}
+
+	return nil
+}
+
+// remountAll makes every mount of the sandbox read-only.
+func remountAll() error {
+	data, err := ioutil.ReadFile("/proc/self/mountinfo")
+	if err != nil {
+		return fmt.Errorf("unable to list mounts: %w", err)
+	}
+
+	for _, line := range strings.Split(string(data), "\n") {
+		// the mount point is the fifth field, with spaces and the like escaped in octal
+		var fields = strings.Fields(line)
+		if len(fields) < 5 {
+			continue
+		}
+
+		var path = unescapeMountPath(fields[4])
+		if err := remountReadOnly(path); err != nil {
+			// mounts hidden under others or that went away can't be reached anymore
+			if errors.Is(err, unix.ENOENT) || errors.Is(err, unix.EACCES) {
+				continue
+			}
+
+			return fmt.Errorf("unable to make %s read-only: %w", path, err)
+		}
+	}
+
+	return nil
+}
+
+// unescapeMountPath undoes the octal escapes of a path in /proc/self/mountinfo.
+func unescapeMountPath(path string) string {
+	if !strings.Contains(path, "\\") {
+		return path
+	}
+
+	var b strings.Builder
+	for idx := 0; idx < len(path); idx++ {
+		if path[idx] == '\\' && idx+3 < len(path) {
+			if c, err := strconv.ParseUint(path[idx+1:idx+4], 8, 8); err == nil {
+				b.WriteByte(byte(c))
+				idx += 3
+				continue
+			}
+		}
+
+		b.WriteByte(path[idx])
+	}
+
+	return b.String()
+}
+
+// bindReadOnly binds the file or directory f read-only at path, creating path if it's gone.
+func bindReadOnly(f *os.File, path string) error {
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+
+	if _, err := os.Lstat(path); os.IsNotExist(err) {
+		if info.IsDir() {
+			err = os.MkdirAll(path, 0755)
+		} else if err = os.MkdirAll(filepath.Dir(path), 0755); err == nil {
+			err = ioutil.WriteFile(path, nil, 0644)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	var src = fmt.Sprintf("/proc/self/fd/%d", f.Fd())
+	if err := unix.Mount(src, path, "", unix.MS_BIND|unix.MS_REC, ""); err != nil {
+		return err
+	}
+
+	return remountReadOnly(path)
+}
+
+// remountReadOnly makes the mount at path read-only, keeping the flags that can't be cleared.
+func remountReadOnly(path string) error {
+	var st unix.Statfs_t
+	if err := unix.Statfs(path, &st); err != nil {
+		return err
+	}
+
+	var flags uintptr = unix.MS_BIND | unix.MS_REMOUNT | unix.MS_RDONLY
+	for _, lf := range lockedFlags {
+		if uintptr(st.Flags)&lf.st != 0 {
+			flags |= lf.ms
+		}
+	}
+
+	return unix.Mount("", path, "", flags, "")
+}
+
+// runExec installs the seccomp filter, if any, and execs the executable.
+func runExec(args []string) error {
+	opts, argv, err := splitArgs(args, 1)
+	if err != nil {
+		return err
+	}
+
+	var path = argv[0]
+	if !strings.Contains(path, "/") {
+		if path, err = exec.LookPath(path); err != nil {
+			return err
+		}
+	}
+
+	var env = os.Environ()
+
+	// the filter only applies to the thread installing it, which has to be the one calling execve
+	runtime.LockOSThread()
+
+	if opts[0] != "" {
+		filter, err := seccompFilter(strings.Split(opts[0], ","))
+		if err != nil {
+			return err
+		}
+
+		if err := unix.Prctl(unix.PR_SET_NO_NEW_PRIVS, 1, 0, 0, 0); err != nil {
+			return fmt.Errorf("unable to set no_new_privs: %w", err)
+		}
+
+		var prog = unix.SockFprog{
+			Len:    uint16(len(filter)),
+			Filter: &filter[0],
+		}
+		if err := unix.Prctl(unix.PR_SET_SECCOMP, unix.SECCOMP_MODE_FILTER, uintptr(unsafe.Pointer(&prog)), 0, 0); err != nil {
+			return fmt.Errorf("unable to install seccomp filter: %w", err)
+		}
+	}
+
+	return unix.Exec(path, argv, env)
+}
+
+// seccompFilter returns a BPF program allowing only the named system calls, and execve;
+// any other call fails with EPERM.
+func seccompFilter(names []string) ([]unix.SockFilter, error) {
+	var filter = []unix.SockFilter{
+		bpfStmt(unix.BPF_LD|unix.BPF_W|unix.BPF_ABS, seccompDataArch),
+		bpfJump(unix.BPF_JMP|unix.BPF_JEQ|unix.BPF_K, auditArch, 1, 0),
+		bpfStmt(unix.BPF_RET|unix.BPF_K, seccompRetKillProcess),
+		bpfStmt(unix.BPF_LD|unix.BPF_W|unix.BPF_ABS, seccompDataNr),
+	}
+
+	for _, name := range append([]string{"execve"}, names...) {
+		nr, ok := syscallNumbers[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown system call %q in seccomp allowlist", name)
+		}
+
+		filter = append(filter,
+			bpfJump(unix.BPF_JMP|unix.BPF_JEQ|unix.BPF_K, nr, 0, 1),
+			bpfStmt(unix.BPF_RET|unix.BPF_K, seccompRetAllow),
+		)
+	}
+
+	return append(filter, bpfStmt(unix.BPF_RET|unix.BPF_K, seccompRetErrno|uint32(unix.EPERM))), nil
+}
+
+func bpfStmt(code uint16, k uint32) unix.SockFilter {
+	return unix.SockFilter{Code: code, K: k}
+}
+
+func bpfJump(code uint16, k uint32, jt, jf uint8) unix.SockFilter {
+	return unix.SockFilter{Code: code, Jt: jt, Jf: jf, K: k}
+}
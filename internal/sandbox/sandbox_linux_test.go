@@ -0,0 +1,93 @@
+package sandbox
+
+import (
+	"testing"
+
+	"golang.org/x/sys/unix"
+)
+
+// runFilter runs a seccomp filter against a system call made on arch, returning what it decided.
+// It only knows the instructions seccompFilter emits.
+func runFilter(t *testing.T, filter []unix.SockFilter, arch, nr uint32) uint32 {
+	var acc uint32
+	for pc := 0; pc < len(filter); pc++ {
+		var ins = filter[pc]
+		switch ins.Code {
+		case unix.BPF_LD | unix.BPF_W | unix.BPF_ABS:
+			switch ins.K {
+			case seccompDataNr:
+				acc = nr
+			case seccompDataArch:
+				acc = arch
+			default:
+				t.Fatalf("unexpected load from offset %d", ins.K)
+			}
+		case unix.BPF_JMP | unix.BPF_JEQ | unix.BPF_K:
+			if acc == ins.K {
+				pc += int(ins.Jt)
+			} else {
+				pc += int(ins.Jf)
+			}
+		case unix.BPF_RET | unix.BPF_K:
+			return ins.K
+		default:
+			t.Fatalf("unexpected instruction %#x", ins.Code)
+		}
+	}
+
+	t.Fatal("filter ran past its end")
+	return 0
+}
+
+func TestSeccompFilter(t *testing.T) {
+	if syscallNumbers == nil {
+		t.Skip("seccomp allowlists aren't supported on this architecture")
+	}
+
+	filter, err := seccompFilter([]string{"read", "write"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var tests = []struct {
+		name string
+		arch uint32
+		nr   uint32
+		want uint32
+	}{
+		{name: "allowed", arch: auditArch, nr: syscallNumbers["read"], want: seccompRetAllow},
+		{name: "last allowed", arch: auditArch, nr: syscallNumbers["write"], want: seccompRetAllow},
+		{name: "execve", arch: auditArch, nr: syscallNumbers["execve"], want: seccompRetAllow},
+		{name: "not allowed", arch: auditArch, nr: syscallNumbers["mkdir"], want: seccompRetErrno | uint32(unix.EPERM)},
+		{name: "other architecture", arch: auditArch + 1, nr: syscallNumbers["read"], want: seccompRetKillProcess},
+	}
+
+	for _, tt := range tests {
+		if got := runFilter(t, filter, tt.arch, tt.nr); got != tt.want {
+			t.Errorf("%s: expected %#x, got %#x", tt.name, tt.want, got)
+		}
+	}
+
+	if _, err := seccompFilter([]string{"read", "nope"}); err == nil {
+		t.Error("expected an unknown system call to fail")
+	}
+}
+
+func TestUnescapeMountPath(t *testing.T) {
+	var tests = []struct {
+		path string
+		want string
+	}{
+		{path: "/var/lib/graphqld", want: "/var/lib/graphqld"},
+		{path: `/mnt/my\040disk`, want: "/mnt/my disk"},
+		{path: `/a\011b\012c\134d`, want: "/a\tb\nc\\d"},
+		{path: `/trailing\04`, want: `/trailing\04`},
+		{path: `/not\999octal`, want: `/not\999octal`},
+	}
+
+	for _, tt := range tests {
+		if got := unescapeMountPath(tt.path); got != tt.want {
+			t.Errorf("%q: expected %q, got %q", tt.path, tt.want, got)
+		}
+	}
+}
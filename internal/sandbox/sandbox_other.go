@@ -0,0 +1,29 @@
+//go:build !linux
+// +build !linux
+
+package sandbox
+
+import (
+	"errors"
+	"os/exec"
+)
+
+var errUnsupported = errors.New("sandboxing is only supported on linux")
+
+// Validate reports whether p can be used on this system.
+func (p *Profile) Validate() error {
+	return errUnsupported
+}
+
+// Wrap rewrites cmd so that it runs in the sandbox described by p.
+func Wrap(cmd *exec.Cmd, p *Profile) error {
+	return errUnsupported
+}
+
+func runInit(args []string) error {
+	return errUnsupported
+}
+
+func runExec(args []string) error {
+	return errUnsupported
+}
@@ -0,0 +1,9 @@
+//go:build linux && !amd64 && !arm64
+// +build linux,!amd64,!arm64
+
+package sandbox
+
+// seccomp allowlists aren't supported on this architecture.
+const auditArch = 0
+
+var syscallNumbers map[string]uint32
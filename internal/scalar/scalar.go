@@ -0,0 +1,345 @@
+// Package scalar holds the custom scalars graphqld provides out of the box.
+package scalar
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"net/mail"
+	"net/url"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/ast"
+)
+
+const (
+	dateLayout = "2006-01-02"
+)
+
+var (
+	timeLayouts = []string{"15:04:05", "15:04:05.999999999", "15:04:05Z07:00", "15:04:05.999999999Z07:00"}
+
+	uuidRegexp = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+)
+
+// Library holds the scalars that can be used by name, whether declared in a schema file or not.
+var Library = map[string]*graphql.Scalar{
+	"JSON":   JSON,
+	"Date":   Date,
+	"Time":   Time,
+	"BigInt": BigInt,
+	"Long":   Long,
+	"URL":    URL,
+	"Email":  Email,
+	"UUID":   UUID,
+	"Upload": Upload,
+}
+
+// JSON is any JSON value.
+var JSON = graphql.NewScalar(graphql.ScalarConfig{
+	Name:        "JSON",
+	Description: "Any JSON value.",
+	Serialize: func(value interface{}) interface{} {
+		return value
+	},
+	ParseValue: func(value interface{}) interface{} {
+		return value
+	},
+	ParseLiteral: func(valueAST ast.Value) interface{} {
+		return ValueFromLiteral(valueAST)
+	},
+})
+
+// Date is a calendar date such as 2006-01-02.
+var Date = newStringScalar("Date", "A calendar date such as 2006-01-02.", func(value interface{}) (string, error) {
+	switch x := value.(type) {
+	case time.Time:
+		return x.Format(dateLayout), nil
+	case string:
+		if _, err := time.Parse(dateLayout, x); err != nil {
+			return "", err
+		}
+		return x, nil
+	}
+
+	return "", errNotA(value)
+})
+
+// Time is a time of day such as 15:04:05, with optional fractional seconds and offset.
+var Time = newStringScalar("Time", "A time of day such as 15:04:05, with optional fractional seconds and offset.", func(value interface{}) (string, error) {
+	switch x := value.(type) {
+	case time.Time:
+		return x.Format("15:04:05.999999999Z07:00"), nil
+	case string:
+		for _, layout := range timeLayouts {
+			if _, err := time.Parse(layout, x); err == nil {
+				return x, nil
+			}
+		}
+		return "", fmt.Errorf("%q is not a time of day", x)
+	}
+
+	return "", errNotA(value)
+})
+
+// URL is an absolute URL.
+var URL = newStringScalar("URL", "An absolute URL.", func(value interface{}) (string, error) {
+	s, ok := value.(string)
+	if !ok {
+		if u, ok := value.(*url.URL); ok {
+			s = u.String()
+		} else {
+			return "", errNotA(value)
+		}
+	}
+
+	u, err := url.Parse(s)
+	if err != nil {
+		return "", err
+	}
+	if u.Scheme == "" || (u.Host == "" && u.Opaque == "") {
+		return "", fmt.Errorf("%q is not an absolute URL", s)
+	}
+
+	return s, nil
+})
+
+// Email is an email address, without a display name.
+var Email = newStringScalar("Email", "An email address.", func(value interface{}) (string, error) {
+	s, ok := value.(string)
+	if !ok {
+		return "", errNotA(value)
+	}
+
+	addr, err := mail.ParseAddress(s)
+	if err != nil || addr.Address != s {
+		return "", fmt.Errorf("%q is not an email address", s)
+	}
+
+	return s, nil
+})
+
+// UUID is a UUID in its canonical textual form.
+var UUID = newStringScalar("UUID", "A UUID such as 123e4567-e89b-12d3-a456-426614174000.", func(value interface{}) (string, error) {
+	s, ok := value.(string)
+	if !ok || !uuidRegexp.MatchString(s) {
+		return "", fmt.Errorf("%v is not a UUID", value)
+	}
+
+	return s, nil
+})
+
+// BigInt is an integer of any size.
+var BigInt = graphql.NewScalar(graphql.ScalarConfig{
+	Name:        "BigInt",
+	Description: "An integer of any size.",
+	Serialize: func(value interface{}) interface{} {
+		i, err := bigIntFrom(value)
+		if err != nil {
+			panic(fmt.Errorf("BigInt cannot represent %v: %w", value, err))
+		}
+		return i
+	},
+	ParseValue: func(value interface{}) interface{} {
+		i, err := bigIntFrom(value)
+		if err != nil {
+			return nil
+		}
+		return i
+	},
+	ParseLiteral: func(valueAST ast.Value) interface{} {
+		switch x := valueAST.(type) {
+		case *ast.IntValue:
+			i, err := bigIntFrom(x.Value)
+			if err != nil {
+				return nil
+			}
+			return i
+		case *ast.StringValue:
+			i, err := bigIntFrom(x.Value)
+			if err != nil {
+				return nil
+			}
+			return i
+		}
+		return nil
+	},
+})
+
+// Long is a 64-bit signed integer.
+var Long = graphql.NewScalar(graphql.ScalarConfig{
+	Name:        "Long",
+	Description: "A 64-bit signed integer.",
+	Serialize: func(value interface{}) interface{} {
+		i, err := longFrom(value)
+		if err != nil {
+			panic(fmt.Errorf("Long cannot represent %v: %w", value, err))
+		}
+		return i
+	},
+	ParseValue: func(value interface{}) interface{} {
+		i, err := longFrom(value)
+		if err != nil {
+			return nil
+		}
+		return i
+	},
+	ParseLiteral: func(valueAST ast.Value) interface{} {
+		switch x := valueAST.(type) {
+		case *ast.IntValue:
+			i, err := longFrom(x.Value)
+			if err != nil {
+				return nil
+			}
+			return i
+		case *ast.StringValue:
+			i, err := longFrom(x.Value)
+			if err != nil {
+				return nil
+			}
+			return i
+		}
+		return nil
+	},
+})
+
+// newStringScalar creates a scalar represented by a string, checked and normalized by coerce.
+func newStringScalar(name, description string, coerce func(interface{}) (string, error)) *graphql.Scalar {
+	return graphql.NewScalar(graphql.ScalarConfig{
+		Name:        name,
+		Description: description,
+		Serialize: func(value interface{}) interface{} {
+			s, err := coerce(value)
+			if err != nil {
+				// graphql-go reports panics as the fields error
+				panic(fmt.Errorf("%s cannot represent %v: %w", name, value, err))
+			}
+			return s
+		},
+		ParseValue: func(value interface{}) interface{} {
+			s, err := coerce(value)
+			if err != nil {
+				return nil
+			}
+			return s
+		},
+		ParseLiteral: func(valueAST ast.Value) interface{} {
+			x, ok := valueAST.(*ast.StringValue)
+			if !ok {
+				return nil
+			}
+
+			s, err := coerce(x.Value)
+			if err != nil {
+				return nil
+			}
+			return s
+		},
+	})
+}
+
+func errNotA(value interface{}) error {
+	return fmt.Errorf("unexpected %T", value)
+}
+
+func bigIntFrom(value interface{}) (*big.Int, error) {
+	var i = new(big.Int)
+
+	switch x := value.(type) {
+	case *big.Int:
+		return x, nil
+	case string:
+		if _, ok := i.SetString(x, 10); !ok {
+			return nil, fmt.Errorf("%q is not an integer", x)
+		}
+	case json.Number:
+		if _, ok := i.SetString(string(x), 10); !ok {
+			return nil, fmt.Errorf("%s is not an integer", x)
+		}
+	case int:
+		i.SetInt64(int64(x))
+	case int64:
+		i.SetInt64(x)
+	case float64:
+		if x != math.Trunc(x) || math.IsInf(x, 0) {
+			return nil, fmt.Errorf("%v is not an integer", x)
+		}
+		new(big.Float).SetFloat64(x).Int(i)
+	default:
+		return nil, errNotA(value)
+	}
+
+	return i, nil
+}
+
+func longFrom(value interface{}) (int64, error) {
+	switch x := value.(type) {
+	case int64:
+		return x, nil
+	case int:
+		return int64(x), nil
+	case string:
+		return strconv.ParseInt(x, 10, 64)
+	case json.Number:
+		return strconv.ParseInt(string(x), 10, 64)
+	case float64:
+		if x != math.Trunc(x) || x < math.MinInt64 || x >= math.MaxInt64 {
+			return 0, fmt.Errorf("%v is not a 64-bit integer", x)
+		}
+		return int64(x), nil
+	}
+
+	return 0, errNotA(value)
+}
+
+// ValueFromLiteral returns the Go value of a literal from a query, as JSON would decode it.
+func ValueFromLiteral(valueAST ast.Value) interface{} {
+	switch x := valueAST.(type) {
+	case *ast.StringValue:
+		return x.Value
+	case *ast.BooleanValue:
+		return x.Value
+	case *ast.IntValue:
+		return json.Number(x.Value)
+	case *ast.FloatValue:
+		return json.Number(x.Value)
+	case *ast.EnumValue:
+		return x.Value
+	case *ast.ListValue:
+		var values = make([]interface{}, len(x.Values))
+		for idx, v := range x.Values {
+			values[idx] = ValueFromLiteral(v)
+		}
+		return values
+	case *ast.ObjectValue:
+		var values = make(map[string]interface{}, len(x.Fields))
+		for _, f := range x.Fields {
+			values[f.Name.Value] = ValueFromLiteral(f.Value)
+		}
+		return values
+	}
+
+	return nil
+}
+
+// NewPassthrough returns a scalar that takes any value as is, for scalars with neither
+// a library implementation nor a coercion executable.
+func NewPassthrough(name, description string) *graphql.Scalar {
+	return graphql.NewScalar(graphql.ScalarConfig{
+		Name:        name,
+		Description: description,
+		Serialize: func(value interface{}) interface{} {
+			return value
+		},
+		ParseValue: func(value interface{}) interface{} {
+			return value
+		},
+		ParseLiteral: func(valueAST ast.Value) interface{} {
+			return ValueFromLiteral(valueAST)
+		},
+	})
+}
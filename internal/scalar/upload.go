@@ -0,0 +1,37 @@
+package scalar
+
+import (
+	"errors"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/ast"
+)
+
+// File is a file uploaded with a multipart request; resolvers are handed it as JSON.
+type File struct {
+	Filename    string `json:"filename"`
+	ContentType string `json:"contentType"`
+	Size        int64  `json:"size"`
+
+	// Path is where the file was saved for the duration of the request.
+	Path string `json:"path"`
+}
+
+// Upload is a file uploaded with a multipart request.
+// Only the server creates its values, clients can't pass one as a variable or literal.
+var Upload = graphql.NewScalar(graphql.ScalarConfig{
+	Name:        "Upload",
+	Description: "A file uploaded with a multipart request.",
+	Serialize: func(value interface{}) interface{} {
+		panic(errors.New("Upload is an input only type"))
+	},
+	ParseValue: func(value interface{}) interface{} {
+		if f, ok := value.(*File); ok {
+			return f
+		}
+		return nil
+	},
+	ParseLiteral: func(valueAST ast.Value) interface{} {
+		return nil
+	},
+})
@@ -0,0 +1,66 @@
+package scan
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/graphql-go/graphql/gqlerrors"
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/graphql-go/graphql/language/location"
+)
+
+// Error is a problem with the file at Path, located at Line and Column when they're known.
+type Error struct {
+	Path         string
+	Line, Column int
+
+	Err error
+}
+
+func (e *Error) Error() string {
+	if e.Line != 0 {
+		return fmt.Sprintf("%s:%d:%d: %s", e.Path, e.Line, e.Column, e.Err)
+	}
+
+	return fmt.Sprintf("%s: %s", e.Path, e.Err)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// newSyntaxError returns the Error for err, as returned by the graphql-go parser for the file at path.
+func newSyntaxError(path string, err error) *Error {
+	var (
+		se *gqlerrors.Error
+		e  = Error{Path: path, Err: err}
+	)
+
+	if errors.As(err, &se) {
+		if 0 < len(se.Locations) {
+			e.Line = se.Locations[0].Line
+			e.Column = se.Locations[0].Column
+		}
+
+		// graphql-go messages start with their location and end with an excerpt of the source
+		var msg = strings.SplitN(se.Message, "\n", 2)[0]
+		if idx := strings.Index(msg, ") "); strings.HasPrefix(msg, "Syntax Error") && idx != -1 {
+			msg = msg[idx+2:]
+		}
+		e.Err = errors.New(msg)
+	}
+
+	return &e
+}
+
+// locatedError returns the Error for err about what's at loc in the file at path.
+func locatedError(path string, loc *ast.Location, err error) *Error {
+	var e = Error{Path: path, Err: err}
+	if loc != nil && loc.Source != nil {
+		l := location.GetLocation(loc.Source, loc.Start)
+		e.Line, e.Column = l.Line, l.Column
+	}
+
+	return &e
+}
@@ -6,12 +6,12 @@ import (
 	"fmt"
 	"os/exec"
 	"path/filepath"
-	"strings"
 	"syscall"
 
 	"github.com/graphql-go/graphql/language/ast"
-	"github.com/graphql-go/graphql/language/parser"
 	"github.com/raphaelreyna/graphqld/internal/config"
+	"github.com/raphaelreyna/graphqld/internal/limits"
+	"github.com/raphaelreyna/graphqld/internal/sandbox"
 )
 
 var ErrNotAResolver = errors.New("not a resolver")
@@ -21,6 +21,13 @@ type ExecFile struct {
 
 	ObjectName string
 	Fields     []*ast.FieldDefinition
+
+	// Batch is true if the executable resolves all sibling invocations of its field at once.
+	Batch bool
+
+	// Limits and Sandbox are applied to the executable while it lists its fields.
+	Limits  config.Limits
+	Sandbox *sandbox.Profile
 }
 
 func (ef *ExecFile) Path() string {
@@ -55,54 +62,45 @@ func (ef *ExecFile) Scan() error {
 			}
 		}
 
-		schemaBytes, err := cmd.CombinedOutput()
-		if err != nil {
-			return fmt.Errorf(
-				"error executing %s --graphqld-fields: %w",
-				path, ErrNotAResolver,
-			)
-		}
-
-		if err := json.Unmarshal(schemaBytes, &fieldStrings); err != nil {
-			return fmt.Errorf(
-				"error parsing json output of %s --graphqld-fields: %w",
-				path, err,
-			)
+		if ef.Sandbox != nil {
+			if err := sandbox.Wrap(cmd, ef.Sandbox); err != nil {
+				return &Error{Path: path, Err: fmt.Errorf("unable to sandbox: %w", err)}
+			}
 		}
-	}
 
-	// parse field strings
-	{
-		parsedOutput, err := parser.Parse(parser.ParseParams{
-			Source: fmt.Sprintf(
-				"type Query {\n\t%s\n}",
-				strings.Join(fieldStrings, "\n\t"),
-			),
-		})
+		schemaBytes, err := limits.Output(cmd, ef.Limits)
 		if err != nil {
-			return fmt.Errorf(
-				"error parsing fields returned by %s: %w",
-				path, err,
-			)
+			return &Error{Path: path, Err: fmt.Errorf(
+				"--graphqld-fields failed (%v): %w",
+				err, ErrNotAResolver,
+			)}
 		}
 
-		if len(parsedOutput.Definitions) != 1 {
-			return fmt.Errorf(
-				"error parsing fields returned by %s: expected 1 definition",
-				path,
-			)
-		}
+		// the output is either a list of fields or an object
+		// listing the fields along with any options
+		if err := json.Unmarshal(schemaBytes, &fieldStrings); err != nil {
+			var opts struct {
+				Fields []string `json:"fields"`
+				Batch  bool     `json:"batch"`
+			}
 
-		objDef, ok := parsedOutput.Definitions[0].(*ast.ObjectDefinition)
-		if !ok {
-			return fmt.Errorf(
-				"error parsing fields returned by %s: no object definition found",
-				path,
-			)
+			if err := json.Unmarshal(schemaBytes, &opts); err != nil {
+				return &Error{Path: path, Err: fmt.Errorf(
+					"--graphqld-fields output is neither a JSON list of fields nor an object: %w",
+					err,
+				)}
+			}
+
+			fieldStrings = opts.Fields
+			ef.Batch = opts.Batch
 		}
+	}
 
-		ef.Fields = objDef.Fields
+	fields, err := parseFields(path, fieldStrings)
+	if err != nil {
+		return err
 	}
+	ef.Fields = fields
 
 	return nil
 }
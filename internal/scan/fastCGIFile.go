@@ -0,0 +1,70 @@
+package scan
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/raphaelreyna/graphqld/internal/config"
+	"gopkg.in/yaml.v2"
+)
+
+// FastCGIFile is a YAML descriptor binding a field to a FastCGI application:
+//
+//	field: "greet(name: String!): String"
+//	address: "unix:/run/php/php-fpm.sock"
+//	script: "/srv/resolvers/greet.php"
+type FastCGIFile struct {
+	Dir, Name string
+
+	ObjectName string
+	Fields     []*ast.FieldDefinition
+	Conf       config.FastCGIConf
+}
+
+func (ff *FastCGIFile) Path() string {
+	return filepath.Join(ff.Dir, ff.Name+".fcgi")
+}
+
+func (ff *FastCGIFile) Scan() error {
+	var (
+		path = ff.Path()
+		desc struct {
+			Field   string `yaml:"field"`
+			Address string `yaml:"address"`
+			Script  string `yaml:"script"`
+		}
+	)
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return &Error{Path: path, Err: err}
+	}
+
+	if err := yaml.Unmarshal(data, &desc); err != nil {
+		return &Error{Path: path, Err: err}
+	}
+
+	if desc.Field == "" {
+		return &Error{Path: path, Err: fmt.Errorf("missing field: %w", ErrNoFields)}
+	}
+
+	if desc.Address == "" {
+		return &Error{Path: path, Err: errors.New("missing address")}
+	}
+
+	fields, err := parseFields(path, []string{desc.Field})
+	if err != nil {
+		return err
+	}
+
+	ff.Fields = fields
+	ff.Conf = config.FastCGIConf{
+		Address: desc.Address,
+		Script:  desc.Script,
+	}
+
+	return nil
+}
@@ -1,15 +1,34 @@
 package scan
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+	"unicode/utf8"
 
+	"github.com/graphql-go/graphql/gqlerrors"
 	"github.com/graphql-go/graphql/language/ast"
+	"github.com/graphql-go/graphql/language/lexer"
 	"github.com/graphql-go/graphql/language/parser"
+	"github.com/graphql-go/graphql/language/source"
 )
 
+// extendKeyword starts a type extension; graphql-go only parses extensions of objects,
+// so the keyword is blanked out of other extensions and the extended definition is parsed like any other.
+const extendKeyword = "extend"
+
+// extendable are the keywords of the definitions whose extensions graphql-go can't parse.
+var extendable = map[string]bool{
+	"interface": true,
+	"union":     true,
+	"enum":      true,
+	"input":     true,
+	"scalar":    true,
+}
+
 type GraphqlFile struct {
 	Dir, Name string
 
@@ -17,6 +36,11 @@ type GraphqlFile struct {
 	Inputs     []*ast.InputObjectDefinition
 	Enums      []*ast.EnumDefinition
 	Interfaces []*ast.InterfaceDefinition
+	Unions     []*ast.UnionDefinition
+	Scalars    []*ast.ScalarDefinition
+
+	// Extensions holds the objects, inputs, enums, interfaces and unions extending types defined elsewhere.
+	Extensions []ast.Node
 }
 
 func (gf *GraphqlFile) Path() string {
@@ -36,23 +60,14 @@ func (gf *GraphqlFile) Scan() error {
 		return nil
 	}
 
-	filename := file.Name()
 	data, err := io.ReadAll(file)
 	if err != nil {
-		return fmt.Errorf(
-			"error reading file %s: %w",
-			filename, err,
-		)
+		return &Error{Path: path, Err: err}
 	}
 
-	parsedOutput, err := parser.Parse(parser.ParseParams{
-		Source: string(data),
-	})
+	parsedOutput, extended, err := parse(path, data)
 	if err != nil {
-		return fmt.Errorf(
-			"error parsing fields returned by %s: %w",
-			filename, err,
-		)
+		return newSyntaxError(path, err)
 	}
 
 	if gf.Objects == nil {
@@ -67,8 +82,34 @@ func (gf *GraphqlFile) Scan() error {
 	if gf.Interfaces == nil {
 		gf.Interfaces = []*ast.InterfaceDefinition{}
 	}
+	if gf.Unions == nil {
+		gf.Unions = []*ast.UnionDefinition{}
+	}
+	if gf.Scalars == nil {
+		gf.Scalars = []*ast.ScalarDefinition{}
+	}
+
+	if gf.Extensions == nil {
+		gf.Extensions = []ast.Node{}
+	}
 
 	for _, def := range parsedOutput.Definitions {
+		if x, ok := def.(*ast.TypeExtensionDefinition); ok {
+			gf.Extensions = append(gf.Extensions, x.Definition)
+			continue
+		}
+
+		if name := definitionName(def); name != nil && name.Loc != nil && extended[name.Loc.Start] {
+			switch def.(type) {
+			case *ast.ObjectDefinition, *ast.InputObjectDefinition, *ast.EnumDefinition,
+				*ast.InterfaceDefinition, *ast.UnionDefinition:
+				gf.Extensions = append(gf.Extensions, def)
+				continue
+			default:
+				return locatedError(path, name.Loc, fmt.Errorf("unsupported extension of %s", name.Value))
+			}
+		}
+
 		switch x := def.(type) {
 		case *ast.ObjectDefinition:
 			gf.Objects = append(gf.Objects, x)
@@ -78,10 +119,123 @@ func (gf *GraphqlFile) Scan() error {
 			gf.Enums = append(gf.Enums, x)
 		case *ast.InterfaceDefinition:
 			gf.Interfaces = append(gf.Interfaces, x)
+		case *ast.UnionDefinition:
+			gf.Unions = append(gf.Unions, x)
+		case *ast.ScalarDefinition:
+			gf.Scalars = append(gf.Scalars, x)
 		default:
-			return fmt.Errorf("unsupported definition type: %T %v", def, def)
+			return locatedError(path, def.GetLoc(), fmt.Errorf("unsupported definition type: %s", def.GetKind()))
 		}
 	}
 
 	return nil
 }
+
+// parse parses the schema file at path holding data. Extensions graphql-go can't parse have their extend keyword blanked out,
+// which is only done where the parser stops at one so that types, fields and values named extend are left alone;
+// parse returns the positions of the names of the types they extend.
+func parse(path string, data []byte) (*ast.Document, map[int]bool, error) {
+	var (
+		extended = make(map[int]bool)
+		stripped bool
+	)
+
+	for {
+		// naming the source after the file locates every definition in it
+		doc, err := parser.Parse(parser.ParseParams{
+			Source: source.NewSource(&source.Source{
+				Body: data,
+				Name: path,
+			}),
+		})
+		if err == nil {
+			return doc, extended, nil
+		}
+
+		keyword, name, ok := extensionAt(data, err)
+		if !ok {
+			return nil, nil, err
+		}
+
+		if !stripped {
+			data = append([]byte{}, data...)
+			stripped = true
+		}
+
+		// token positions count runes rather than bytes
+		var start = byteOffset(data, keyword)
+		copy(data[start:], bytes.Repeat([]byte(" "), len(extendKeyword)))
+
+		extended[name] = true
+	}
+}
+
+// extensionAt returns the positions of the extend keyword and of the name of the type it extends
+// if parsing data failed with err right after the keyword, expecting an object where another kind of type is extended.
+func extensionAt(data []byte, err error) (int, int, bool) {
+	var gqlErr *gqlerrors.Error
+	if !errors.As(err, &gqlErr) || len(gqlErr.Positions) == 0 {
+		return 0, 0, false
+	}
+
+	var (
+		pos = gqlErr.Positions[0]
+		lex = lexer.Lex(source.NewSource(&source.Source{Body: data}))
+
+		prev lexer.Token
+	)
+
+	for {
+		tok, err := lex(0)
+		if err != nil || tok.Kind == lexer.EOF || pos < tok.Start {
+			return 0, 0, false
+		}
+
+		if tok.Start < pos {
+			prev = tok
+			continue
+		}
+
+		if prev.Kind != lexer.NAME || prev.Value != extendKeyword || tok.Kind != lexer.NAME || !extendable[tok.Value] {
+			return 0, 0, false
+		}
+
+		name, err := lex(0)
+		if err != nil || name.Kind != lexer.NAME {
+			return 0, 0, false
+		}
+
+		return prev.Start, name.Start, true
+	}
+}
+
+// byteOffset returns the offset in data of its rune at position pos.
+func byteOffset(data []byte, pos int) int {
+	var offset int
+	for ; 0 < pos && offset < len(data); pos-- {
+		_, size := utf8.DecodeRune(data[offset:])
+		offset += size
+	}
+
+	return offset
+}
+
+// definitionName returns the name of the type defined by def, if it defines one.
+func definitionName(def ast.Node) *ast.Name {
+	switch x := def.(type) {
+	case *ast.ObjectDefinition:
+		return x.Name
+	case *ast.InputObjectDefinition:
+		return x.Name
+	case *ast.EnumDefinition:
+		return x.Name
+	case *ast.InterfaceDefinition:
+		return x.Name
+	case *ast.UnionDefinition:
+		return x.Name
+	case *ast.ScalarDefinition:
+		return x.Name
+	}
+
+	return nil
+}
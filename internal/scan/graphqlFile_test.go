@@ -0,0 +1,118 @@
+package scan
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/graphql-go/graphql/language/ast"
+)
+
+func TestGraphqlFileExtensions(t *testing.T) {
+	var (
+		dir    = t.TempDir()
+		schema = `
+enum Verb {
+  extend
+  fetch
+  store
+}
+
+type Query {
+  verb: Verb
+}
+
+extend type Query {
+  extend(verb: Verb): String
+}
+
+extend enum Verb {
+  drop
+}
+
+input Filter {
+  extend: Boolean
+}
+
+extend input Filter {
+  limit: Int
+}
+
+extend union Result = Query
+`
+	)
+
+	if err := ioutil.WriteFile(filepath.Join(dir, "schema.graphql"), []byte(schema), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	var gf = GraphqlFile{Dir: dir, Name: "schema"}
+	if err := gf.Scan(); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(gf.Enums) != 1 {
+		t.Fatalf("expected 1 enum, got %d", len(gf.Enums))
+	}
+
+	var values []string
+	for _, v := range gf.Enums[0].Values {
+		values = append(values, v.Name.Value)
+	}
+	if len(values) != 3 || values[0] != "extend" || values[1] != "fetch" || values[2] != "store" {
+		t.Errorf("expected the enum value named extend to be left alone, got %v", values)
+	}
+
+	if len(gf.Inputs) != 1 || gf.Inputs[0].Fields[0].Name.Value != "extend" {
+		t.Errorf("expected the input field named extend to be left alone")
+	}
+
+	var kinds []string
+	for _, ext := range gf.Extensions {
+		var name string
+		switch x := ext.(type) {
+		case *ast.ObjectDefinition:
+			name = x.Name.Value
+		case *ast.EnumDefinition:
+			name = x.Name.Value
+		case *ast.InputObjectDefinition:
+			name = x.Name.Value
+		case *ast.UnionDefinition:
+			name = x.Name.Value
+		}
+		kinds = append(kinds, ext.GetKind()+" "+name)
+	}
+
+	var want = []string{
+		"ObjectDefinition Query",
+		"EnumDefinition Verb",
+		"InputObjectDefinition Filter",
+		"UnionDefinition Result",
+	}
+	if len(kinds) != len(want) {
+		t.Fatalf("expected extensions %v, got %v", want, kinds)
+	}
+	for idx := range want {
+		if kinds[idx] != want[idx] {
+			t.Errorf("expected extensions %v, got %v", want, kinds)
+			break
+		}
+	}
+
+	// blanking out keywords leaves every definition where it is in the file
+	if loc := gf.Extensions[1].GetLoc(); loc == nil || loc.Start != len("\nenum Verb {\n  extend\n  fetch\n  store\n}\n\ntype Query {\n  verb: Verb\n}\n\nextend type Query {\n  extend(verb: Verb): String\n}\n\nextend ") {
+		t.Errorf("expected the enum extension to keep its position, got %+v", loc)
+	}
+}
+
+func TestGraphqlFileUnsupportedExtension(t *testing.T) {
+	var dir = t.TempDir()
+	if err := ioutil.WriteFile(filepath.Join(dir, "schema.graphql"), []byte("extend scalar Time\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	var gf = GraphqlFile{Dir: dir, Name: "schema"}
+	if err := gf.Scan(); err == nil {
+		t.Fatal("expected extending a scalar to fail")
+	}
+}
@@ -0,0 +1,70 @@
+package scan
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/raphaelreyna/graphqld/internal/config"
+	"gopkg.in/yaml.v2"
+)
+
+// HTTPFileSuffix is the suffix of descriptor files binding a field to an HTTP endpoint.
+const HTTPFileSuffix = ".http.yaml"
+
+// HTTPFile is a YAML descriptor binding a field to an HTTP endpoint:
+//
+//	field: "user(id: ID!): User"
+//	method: GET
+//	url: "http://users.internal/users/{{ pathescape .args.id }}"
+//	forwardHeaders: ["Authorization"]
+type HTTPFile struct {
+	Dir, Name string
+
+	ObjectName string
+	Fields     []*ast.FieldDefinition
+	Conf       config.HTTPConf
+}
+
+func (hf *HTTPFile) Path() string {
+	return filepath.Join(hf.Dir, hf.Name+HTTPFileSuffix)
+}
+
+func (hf *HTTPFile) Scan() error {
+	var (
+		path = hf.Path()
+		desc struct {
+			Field           string `yaml:"field"`
+			config.HTTPConf `yaml:",inline"`
+		}
+	)
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return &Error{Path: path, Err: err}
+	}
+
+	if err := yaml.Unmarshal(data, &desc); err != nil {
+		return &Error{Path: path, Err: err}
+	}
+
+	if desc.Field == "" {
+		return &Error{Path: path, Err: fmt.Errorf("missing field: %w", ErrNoFields)}
+	}
+
+	if desc.URL == "" {
+		return &Error{Path: path, Err: errors.New("missing url")}
+	}
+
+	fields, err := parseFields(path, []string{desc.Field})
+	if err != nil {
+		return err
+	}
+
+	hf.Fields = fields
+	hf.Conf = desc.HTTPConf
+
+	return nil
+}
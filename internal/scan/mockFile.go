@@ -0,0 +1,37 @@
+package scan
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+)
+
+// MockFileSuffix is the suffix of the files holding the value a field is mocked with.
+const MockFileSuffix = ".mock.json"
+
+// MockFile holds the value of the field Name of the object ObjectName in mock mode, as JSON.
+type MockFile struct {
+	Dir, Name string
+
+	ObjectName string
+	Value      interface{}
+}
+
+func (mf *MockFile) Path() string {
+	return filepath.Join(mf.Dir, mf.Name+MockFileSuffix)
+}
+
+func (mf *MockFile) Scan() error {
+	var path = mf.Path()
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return &Error{Path: path, Err: err}
+	}
+
+	if err := json.Unmarshal(data, &mf.Value); err != nil {
+		return &Error{Path: path, Err: err}
+	}
+
+	return nil
+}
@@ -2,11 +2,19 @@ package scan
 
 import (
 	"errors"
+	"fmt"
 	"io/fs"
 	"path/filepath"
 	"strings"
+
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/graphql-go/graphql/language/parser"
+	"github.com/graphql-go/graphql/language/source"
 )
 
+// TestFileSuffix is the suffix of the test cases of a graph, which are kept in its document root without being part of it.
+const TestFileSuffix = ".test.yaml"
+
 var (
 	ErrNoFields = errors.New("file does not contain any object definition with fields")
 	ErrNoInputs = errors.New("file does not contain any object definition with fields")
@@ -37,6 +45,37 @@ func NewFile(path string, info fs.FileInfo) File {
 	)
 	name = strings.TrimSuffix(name, ext)
 
+	if strings.HasSuffix(filepath.Base(path), TestFileSuffix) {
+		return nil
+	}
+
+	if strings.HasSuffix(filepath.Base(path), MockFileSuffix) {
+		return &MockFile{
+			Dir:  dir,
+			Name: strings.TrimSuffix(filepath.Base(path), MockFileSuffix),
+
+			ObjectName: filepath.Base(dir),
+		}
+	}
+
+	if strings.HasSuffix(filepath.Base(path), HTTPFileSuffix) {
+		return &HTTPFile{
+			Dir:  dir,
+			Name: strings.TrimSuffix(filepath.Base(path), HTTPFileSuffix),
+
+			ObjectName: filepath.Base(dir),
+		}
+	}
+
+	if ext == ".fcgi" {
+		return &FastCGIFile{
+			Dir:  dir,
+			Name: name,
+
+			ObjectName: filepath.Base(dir),
+		}
+	}
+
 	if isUserExec(info) {
 		return &ExecFile{
 			Dir:  dir,
@@ -57,3 +96,40 @@ func NewFile(path string, info fs.FileInfo) File {
 
 	return nil
 }
+
+// parseFields parses field definitions, such as those output by an executable
+// given the --graphqld-fields flag. The definitions are located in the file at path.
+func parseFields(path string, fieldStrings []string) ([]*ast.FieldDefinition, error) {
+	parsedOutput, err := parser.Parse(parser.ParseParams{
+		Source: source.NewSource(&source.Source{
+			Body: []byte(fmt.Sprintf(
+				"type Query {\n\t%s\n}",
+				strings.Join(fieldStrings, "\n\t"),
+			)),
+			Name: path,
+		}),
+	})
+	if err != nil {
+		// each field is on a line of its own, after the first one
+		var e = newSyntaxError(path, err)
+		if idx := e.Line - 2; 0 <= idx && idx < len(fieldStrings) {
+			e.Err = fmt.Errorf("invalid field %q: %s", fieldStrings[idx], e.Err)
+		} else {
+			e.Err = fmt.Errorf("invalid fields: %s", e.Err)
+		}
+		e.Line, e.Column = 0, 0
+
+		return nil, e
+	}
+
+	if len(parsedOutput.Definitions) != 1 {
+		return nil, &Error{Path: path, Err: errors.New("invalid fields: expected 1 definition")}
+	}
+
+	objDef, ok := parsedOutput.Definitions[0].(*ast.ObjectDefinition)
+	if !ok {
+		return nil, &Error{Path: path, Err: errors.New("invalid fields: no object definition found")}
+	}
+
+	return objDef.Fields, nil
+}
@@ -1,32 +1,39 @@
 package server
 
 import (
+	"context"
 	"encoding/json"
-	"net"
+	"errors"
+	"fmt"
 	"net/http"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/friendsofgo/graphiql"
 	"github.com/gorilla/handlers"
 	"github.com/gorilla/mux"
+	"github.com/gorilla/websocket"
 	"github.com/graphql-go/graphql"
-	"github.com/graphql-go/handler"
 	"github.com/radovskyb/watcher"
 	"github.com/raphaelreyna/graphqld/internal/config"
 	"github.com/raphaelreyna/graphqld/internal/graph"
 	"github.com/raphaelreyna/graphqld/internal/middleware"
-	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 )
 
-type server struct {
+// GraphServer serves a graph over HTTP, rebuilding it whenever its document root changes if it's hot reloaded.
+type GraphServer struct {
 	conf config.GraphConf
 
-	addr string
-	port string
+	handler  http.Handler
+	requests *middleware.Graph
 
 	schema graphql.Schema
+	graph  *graph.Graph
+
+	// inflight counts the requests using the current build of the graph, which is closed once they're done after being replaced.
+	inflight *sync.WaitGroup
 
 	w *watcher.Watcher
 
@@ -35,20 +42,18 @@ type server struct {
 	sync.RWMutex
 }
 
-func newServer(addr string, mux *mux.Router, conf config.GraphConf) (*server, error) {
-	var s = server{
-		conf:  conf,
-		addr:  addr,
-		close: make(chan struct{}),
-	}
+// NewGraphServer returns a GraphServer for the graph configured by conf; the graph isn't built until it's started.
+func NewGraphServer(conf config.GraphConf) (*GraphServer, error) {
+	var (
+		mux = mux.NewRouter()
 
-	{
-		_, port, err := net.SplitHostPort(s.addr)
-		if err != nil {
-			return nil, err
+		s = GraphServer{
+			conf:     conf,
+			handler:  mux,
+			requests: middleware.NewGraph(conf),
+			close:    make(chan struct{}),
 		}
-		s.port = port
-	}
+	)
 
 	{
 		if cc := s.conf.CORS; cc != nil {
@@ -100,9 +105,14 @@ func newServer(addr string, mux *mux.Router, conf config.GraphConf) (*server, er
 		mux.Handle("/graphiql", handler)
 	}
 
+	if conf.SchemaEndpoints {
+		mux.HandleFunc("/schema.graphql", s.serveSDL)
+		mux.HandleFunc("/schema.json", s.serveIntrospection)
+	}
+
 	mux.Use(middleware.Log)
 
-	mux.Use(middleware.FromGraphConf(conf))
+	mux.Use(s.requests.Wrap)
 
 	if ba := conf.BasicAuth; ba != nil {
 		mux.Use(
@@ -115,50 +125,57 @@ func newServer(addr string, mux *mux.Router, conf config.GraphConf) (*server, er
 	return &s, nil
 }
 
-func (s *server) UpdateSchema() error {
+// UpdateSchema builds the graph, replacing the previous build once it's done.
+// If the graph can't be built, the previous build is kept and the problems found are returned as graph.Diagnostics.
+func (s *GraphServer) UpdateSchema() error {
 	var (
 		conf = s.conf
 
-		g = graph.Graph{
+		g = &graph.Graph{
 			DocumentRoot: conf.DocumentRoot,
 			ResolverDir:  conf.ResolverDir,
 		}
 	)
 
 	if err := g.Build(&conf); err != nil {
-		var logEvent *zerolog.Event
-		if conf.HotReload {
-			logEvent = log.Error()
-		} else {
-			logEvent = log.Fatal()
-		}
-		logEvent.Err(err).
-			Msg("unable to build graph schema config")
-	} else {
-		var schemaConf graphql.SchemaConfig
+		return err
+	}
 
-		if q := g.Query; q != nil {
-			schemaConf.Query = q
-		}
-		if m := g.Mutation; m != nil {
-			schemaConf.Mutation = m
-		}
+	schema, err := g.Schema()
+	if err != nil {
+		g.Close()
+		return graph.Diagnostics{{File: conf.DocumentRoot, Message: err.Error()}}
+	}
 
-		schema, err := graphql.NewSchema(schemaConf)
-		if err != nil {
-			return err
-		}
+	s.Lock()
+	var old, inflight = s.graph, s.inflight
+	s.schema = schema
+	s.graph = g
+	s.inflight = new(sync.WaitGroup)
+	s.Unlock()
 
-		s.Lock()
-		s.schema = schema
-		s.Unlock()
+	// stop any workers belonging to the previous build of the graph once the requests it's serving are done
+	if old != nil {
+		go func() {
+			inflight.Wait()
+			old.Close()
+		}()
 	}
 
 	return nil
 }
 
-func (s *server) Start() error {
-	var err = s.UpdateSchema()
+// Start builds the graph and, if it's hot reloaded, starts watching its document root for changes.
+// A hot reloaded graph is served even if it can't be built, as it's rebuilt once it changes.
+func (s *GraphServer) Start() error {
+	if err := s.UpdateSchema(); err != nil {
+		if !s.conf.HotReload {
+			return err
+		}
+
+		log.Error().Err(logDiagnostics(err)).
+			Msg("unable to build graph schema config")
+	}
 
 	if s.conf.HotReload {
 		go func() {
@@ -166,7 +183,7 @@ func (s *server) Start() error {
 				select {
 				case <-s.w.Event:
 					if err := s.UpdateSchema(); err != nil {
-						log.Error().Err(err).
+						log.Error().Err(logDiagnostics(err)).
 							Msg("error updating schema")
 					}
 				case err := <-s.w.Error:
@@ -181,10 +198,70 @@ func (s *server) Start() error {
 		go s.w.Start(time.Second)
 	}
 
-	return err
+	return nil
 }
 
-func (s *server) Stop() {
+// logDiagnostics logs each problem found with the graph if err holds them,
+// returning an error summing them up in their place.
+func logDiagnostics(err error) error {
+	var diagnostics graph.Diagnostics
+	if !errors.As(err, &diagnostics) {
+		return err
+	}
+
+	for _, d := range diagnostics {
+		log.Error().
+			Str("location", d.Location()).
+			Msg(d.Message)
+	}
+
+	return fmt.Errorf("%d problems found in the graph", len(diagnostics))
+}
+
+// ServeHTTP serves the graph along with its GraphiQL and schema endpoints if they're enabled.
+func (s *GraphServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.handler.ServeHTTP(w, r)
+}
+
+// Schema returns the schema of the current build of the graph, if it has been built.
+func (s *GraphServer) Schema() (graphql.Schema, bool) {
+	s.RLock()
+	defer s.RUnlock()
+
+	return s.schema, s.graph != nil
+}
+
+// Use returns the schema of the current build of the graph, if it has been built, along with a function to call once done with it;
+// a build that has been replaced isn't closed until every request using it is done.
+func (s *GraphServer) Use() (graphql.Schema, func(), bool) {
+	s.RLock()
+	defer s.RUnlock()
+
+	if s.graph == nil {
+		return s.schema, func() {}, false
+	}
+
+	s.inflight.Add(1)
+	return s.schema, s.inflight.Done, true
+}
+
+// NewContext returns a copy of ctx for running req against the graph; see middleware.Graph.NewContext.
+func (s *GraphServer) NewContext(ctx context.Context, req middleware.Request) (context.Context, func(), error) {
+	return s.requests.NewContext(ctx, req)
+}
+
+// Conf returns the configuration of the graph.
+func (s *GraphServer) Conf() config.GraphConf {
+	return s.conf
+}
+
+func (s *GraphServer) Stop() {
+	s.Lock()
+	if s.graph != nil {
+		s.graph.Close()
+	}
+	s.Unlock()
+
 	if !s.conf.HotReload {
 		return
 	}
@@ -192,25 +269,44 @@ func (s *server) Stop() {
 	s.w.Close()
 }
 
-func (s *server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+func (s *GraphServer) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	if websocket.IsWebSocketUpgrade(r) {
+		s.serveWS(w, r)
+		return
+	}
+
+	if strings.Contains(r.Header.Get("Accept"), "text/event-stream") {
+		s.serveSSE(w, r)
+		return
+	}
+
 	var (
 		ctx    = r.Context()
 		logger = middleware.GetLogger(ctx)
-		opts   = handler.NewRequestOptions(r)
 	)
 
+	opts, dir, cleanup, err := newRequestOptions(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	defer cleanup()
+
+	if dir != "" {
+		ctx = middleware.WithUploadDir(ctx, dir)
+	}
+
 	var params = graphql.Params{
 		RequestString:  opts.Query,
 		VariableValues: opts.Variables,
 		OperationName:  opts.OperationName,
-		Context:        ctx,
 	}
 
-	s.RLock()
-	params.Schema = s.schema
-	s.RUnlock()
+	var done func()
+	params.Schema, done, _ = s.Use()
+	defer done()
 
-	result := graphql.Do(params)
+	result := do(ctx, params)
 
 	w.Header().Add("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(result); err != nil {
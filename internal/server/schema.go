@@ -0,0 +1,41 @@
+package server
+
+import (
+	"io"
+	"net/http"
+
+	"github.com/raphaelreyna/graphqld/internal/graph"
+	"github.com/rs/zerolog/log"
+)
+
+// serveSDL serves the current schema of the graph as SDL.
+func (s *GraphServer) serveSDL(w http.ResponseWriter, r *http.Request) {
+	schema, built := s.Schema()
+	if !built {
+		http.Error(w, "the graph hasn't been built", http.StatusServiceUnavailable)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	io.WriteString(w, graph.PrintSchema(schema))
+}
+
+// serveIntrospection serves the result of the introspection query against the current schema of the graph.
+func (s *GraphServer) serveIntrospection(w http.ResponseWriter, r *http.Request) {
+	schema, built := s.Schema()
+	if !built {
+		http.Error(w, "the graph hasn't been built", http.StatusServiceUnavailable)
+		return
+	}
+
+	data, err := graph.Introspect(schema)
+	if err != nil {
+		log.Error().Err(err).
+			Msg("unable to introspect schema")
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(data)
+}
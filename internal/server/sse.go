@@ -0,0 +1,67 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/graphql-go/graphql"
+	"github.com/raphaelreyna/graphqld/internal/middleware"
+)
+
+// serveSSE serves a request asking for text/event-stream,
+// sending each result as a "next" event followed by a "complete" event once there are no more.
+// The subscription is stopped once the client disconnects.
+func (s *GraphServer) serveSSE(w http.ResponseWriter, r *http.Request) {
+	var (
+		ctx    = r.Context()
+		logger = middleware.GetLogger(ctx)
+	)
+
+	opts, dir, cleanup, err := newRequestOptions(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	defer cleanup()
+
+	if dir != "" {
+		ctx = middleware.WithUploadDir(ctx, dir)
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	var params = graphql.Params{
+		RequestString:  opts.Query,
+		VariableValues: opts.Variables,
+		OperationName:  opts.OperationName,
+	}
+
+	var h = w.Header()
+	h.Set("Content-Type", "text/event-stream")
+	h.Set("Cache-Control", "no-cache")
+	h.Set("Connection", "keep-alive")
+	h.Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	s.execute(ctx, params, func(result *graphql.Result) {
+		data, err := json.Marshal(result)
+		if err != nil {
+			logger.Error().Err(err).
+				Interface("result", *result).
+				Msg("unable to encode result")
+			return
+		}
+
+		fmt.Fprintf(w, "event: next\ndata: %s\n\n", data)
+		flusher.Flush()
+	})
+
+	fmt.Fprint(w, "event: complete\ndata:\n\n")
+	flusher.Flush()
+}
@@ -0,0 +1,131 @@
+package server
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/graphql-go/graphql/language/parser"
+	"github.com/raphaelreyna/graphqld/internal/graph/resolver"
+	"github.com/raphaelreyna/graphqld/internal/middleware"
+)
+
+// execute executes the operation in params, calling send with each of its results:
+// once for queries and mutations, once for each event of a subscription.
+// Every result is executed as an operation of its own, so that several can share a request.
+// It returns once there are no more results; subscriptions end early once ctx is done.
+func (s *GraphServer) execute(ctx context.Context, params graphql.Params, send func(*graphql.Result)) {
+	var done func()
+	params.Schema, done, _ = s.Use()
+	defer done()
+
+	op, fragments := operation(params)
+	if op == nil || op.Operation != ast.OperationTypeSubscription {
+		send(do(middleware.NewOperation(ctx), params))
+		return
+	}
+
+	if rootFields(op.SelectionSet, fragments) != 1 {
+		var name = "Anonymous Subscription"
+		if op.Name != nil {
+			name = fmt.Sprintf("Subscription %q", op.Name.Value)
+		}
+
+		send(&graphql.Result{
+			Errors: gqlerrors.FormatErrors(
+				fmt.Errorf("%s must select only one top level field.", name),
+			),
+		})
+		return
+	}
+
+	// the resolver is stopped once we're done, however that happens
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	subCtx, subscriber := resolver.WithSubscriber(ctx)
+
+	// once the resolver is running, the result is of no interest: its field is resolved for each event
+	var result = do(middleware.NewOperation(subCtx), params)
+	stream := subscriber.Stream()
+	if stream == nil {
+		send(result)
+		return
+	}
+
+	for event := range stream.Events() {
+		send(do(resolver.WithEvent(middleware.NewOperation(ctx), event), params))
+	}
+
+	if errs := stream.Errors(); 0 < len(errs) {
+		send(&graphql.Result{Errors: errs})
+	}
+}
+
+// do executes params in ctx, reporting the errors recorded in it along with its own.
+func do(ctx context.Context, params graphql.Params) *graphql.Result {
+	params.Context = ctx
+
+	result := graphql.Do(params)
+	result.Errors = resolver.FormatErrors(
+		append(result.Errors, middleware.GetErrors(ctx)...),
+	)
+
+	return result
+}
+
+// operation returns the operation params selects along with the fragments of its document,
+// or nil if the request can't be parsed.
+func operation(params graphql.Params) (*ast.OperationDefinition, map[string]*ast.FragmentDefinition) {
+	doc, err := parser.Parse(parser.ParseParams{
+		Source: params.RequestString,
+	})
+	if err != nil {
+		return nil, nil
+	}
+
+	var (
+		op        *ast.OperationDefinition
+		fragments = make(map[string]*ast.FragmentDefinition)
+	)
+	for _, def := range doc.Definitions {
+		switch def := def.(type) {
+		case *ast.OperationDefinition:
+			if op != nil {
+				continue
+			}
+			if params.OperationName == "" || (def.Name != nil && def.Name.Value == params.OperationName) {
+				op = def
+			}
+		case *ast.FragmentDefinition:
+			fragments[def.Name.Value] = def
+		}
+	}
+
+	return op, fragments
+}
+
+// rootFields counts the fields selected by set, looking into fragments.
+func rootFields(set *ast.SelectionSet, fragments map[string]*ast.FragmentDefinition) int {
+	if set == nil {
+		return 0
+	}
+
+	var n int
+	for _, sel := range set.Selections {
+		switch sel := sel.(type) {
+		case *ast.Field:
+			n++
+		case *ast.InlineFragment:
+			n += rootFields(sel.SelectionSet, fragments)
+		case *ast.FragmentSpread:
+			if frag, ok := fragments[sel.Name.Value]; ok {
+				n += rootFields(frag.SelectionSet, fragments)
+			}
+		}
+	}
+
+	return n
+}
@@ -0,0 +1,159 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/graphql-go/handler"
+	"github.com/raphaelreyna/graphqld/internal/scalar"
+)
+
+// maxUploadMemory is how much of a multipart request is held in memory, the rest is written to disk.
+const maxUploadMemory = 32 << 20
+
+// newRequestOptions reads the operation of a request. Multipart requests following the GraphQL multipart request spec
+// have their files saved to a directory of their own and set as *scalar.File values at the variables the map field
+// points them to; dir is that directory and cleanup removes it.
+func newRequestOptions(r *http.Request) (opts *handler.RequestOptions, dir string, cleanup func(), err error) {
+	cleanup = func() {}
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if r.Method != http.MethodPost || mediaType != "multipart/form-data" {
+		return handler.NewRequestOptions(r), "", cleanup, nil
+	}
+
+	if err := r.ParseMultipartForm(maxUploadMemory); err != nil {
+		return nil, "", cleanup, fmt.Errorf("invalid multipart request: %w", err)
+	}
+
+	var form = r.MultipartForm
+	defer form.RemoveAll()
+
+	opts = new(handler.RequestOptions)
+	if err := json.Unmarshal([]byte(r.FormValue("operations")), opts); err != nil {
+		return nil, "", cleanup, fmt.Errorf("invalid operations field: %w", err)
+	}
+
+	var fileMap map[string][]string
+	if err := json.Unmarshal([]byte(r.FormValue("map")), &fileMap); err != nil {
+		return nil, "", cleanup, fmt.Errorf("invalid map field: %w", err)
+	}
+
+	if len(fileMap) == 0 {
+		return opts, "", cleanup, nil
+	}
+
+	if dir, err = ioutil.TempDir("", "graphqld-uploads-"); err != nil {
+		return nil, "", cleanup, fmt.Errorf("unable to create upload directory: %w", err)
+	}
+	cleanup = func() {
+		os.RemoveAll(dir)
+	}
+
+	// resolvers may run as another user
+	if err := os.Chmod(dir, 0755); err != nil {
+		cleanup()
+		return nil, "", func() {}, err
+	}
+
+	if opts.Variables == nil {
+		opts.Variables = make(map[string]interface{})
+	}
+
+	var idx int
+	for name, paths := range fileMap {
+		headers := form.File[name]
+		if len(headers) == 0 {
+			cleanup()
+			return nil, "", func() {}, fmt.Errorf("missing file %q", name)
+		}
+
+		f, err := saveUpload(headers[0], filepath.Join(dir, strconv.Itoa(idx)))
+		if err != nil {
+			cleanup()
+			return nil, "", func() {}, err
+		}
+		idx++
+
+		for _, path := range paths {
+			if err := setVariable(opts.Variables, path, f); err != nil {
+				cleanup()
+				return nil, "", func() {}, err
+			}
+		}
+	}
+
+	return opts, dir, cleanup, nil
+}
+
+// saveUpload copies the uploaded file fh to path.
+func saveUpload(fh *multipart.FileHeader, path string) (*scalar.File, error) {
+	src, err := fh.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer src.Close()
+
+	dst, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("unable to save uploaded file: %w", err)
+	}
+	defer dst.Close()
+
+	n, err := io.Copy(dst, src)
+	if err != nil {
+		return nil, fmt.Errorf("unable to save uploaded file: %w", err)
+	}
+
+	return &scalar.File{
+		Filename:    fh.Filename,
+		ContentType: fh.Header.Get("Content-Type"),
+		Size:        n,
+		Path:        path,
+	}, nil
+}
+
+// setVariable sets the value at an object path such as variables.files.0, as used by the map field.
+func setVariable(variables map[string]interface{}, path string, value interface{}) error {
+	var parts = strings.Split(path, ".")
+	if len(parts) < 2 || parts[0] != "variables" {
+		return fmt.Errorf("invalid file path %q: only variables can be files", path)
+	}
+
+	var container interface{} = variables
+	for idx, part := range parts[1:] {
+		var last = idx == len(parts)-2
+
+		switch x := container.(type) {
+		case map[string]interface{}:
+			if last {
+				x[part] = value
+				return nil
+			}
+			container = x[part]
+		case []interface{}:
+			i, err := strconv.Atoi(part)
+			if err != nil || i < 0 || len(x) <= i {
+				return fmt.Errorf("invalid file path %q", path)
+			}
+			if last {
+				x[i] = value
+				return nil
+			}
+			container = x[i]
+		default:
+			return fmt.Errorf("invalid file path %q", path)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,257 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/graphql-go/graphql"
+	"github.com/raphaelreyna/graphqld/internal/middleware"
+)
+
+// the graphql-transport-ws protocol: https://github.com/enisdenjo/graphql-ws/blob/master/PROTOCOL.md
+const (
+	wsProtocol = "graphql-transport-ws"
+
+	wsInitTimeout = 10 * time.Second
+
+	wsConnectionInit = "connection_init"
+	wsConnectionAck  = "connection_ack"
+	wsPing           = "ping"
+	wsPong           = "pong"
+	wsSubscribe      = "subscribe"
+	wsNext           = "next"
+	wsError          = "error"
+	wsComplete       = "complete"
+
+	wsBadRequest          = 4400
+	wsUnauthorized        = 4401
+	wsSubprotocolRequired = 4406
+	wsInitTimedOut        = 4408
+	wsSubscriberExists    = 4409
+	wsTooManyInits        = 4429
+)
+
+type wsMessage struct {
+	ID      string          `json:"id,omitempty"`
+	Type    string          `json:"type"`
+	Payload json.RawMessage `json:"payload,omitempty"`
+}
+
+type wsSubscribePayload struct {
+	Query         string                 `json:"query"`
+	Variables     map[string]interface{} `json:"variables"`
+	OperationName string                 `json:"operationName"`
+}
+
+// wsConn is a graphql-transport-ws connection and the operations running over it.
+type wsConn struct {
+	conn   *websocket.Conn
+	writeL sync.Mutex
+
+	// operations by id, each cancelled once the client completes it
+	ops  map[string]context.CancelFunc
+	opsL sync.Mutex
+	wg   sync.WaitGroup
+}
+
+// serveWS serves a graphql-transport-ws WebSocket connection.
+// Its subscriptions are stopped once the client completes them or disconnects.
+func (s *GraphServer) serveWS(w http.ResponseWriter, r *http.Request) {
+	var (
+		ctx    = r.Context()
+		logger = middleware.GetLogger(ctx)
+	)
+
+	var upgrader = websocket.Upgrader{
+		Subprotocols: []string{wsProtocol},
+	}
+	if cc := s.conf.CORS; cc != nil && 0 < len(cc.AllowedOrigins) {
+		upgrader.CheckOrigin = func(r *http.Request) bool {
+			var origin = r.Header.Get("Origin")
+			for _, allowed := range cc.AllowedOrigins {
+				if allowed == "*" || allowed == origin {
+					return true
+				}
+			}
+
+			return false
+		}
+	}
+
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		logger.Error().Err(err).
+			Msg("unable to upgrade to websocket")
+		return
+	}
+	defer conn.Close()
+
+	var c = wsConn{
+		conn: conn,
+		ops:  make(map[string]context.CancelFunc),
+	}
+
+	if conn.Subprotocol() != wsProtocol {
+		c.close(wsSubprotocolRequired, "Subprotocol not acceptable")
+		return
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer func() {
+		cancel()
+		c.wg.Wait()
+	}()
+
+	var (
+		acked = false
+		timer = time.AfterFunc(wsInitTimeout, func() {
+			c.close(wsInitTimedOut, "Connection initialisation timeout")
+		})
+	)
+	defer timer.Stop()
+
+	for {
+		var msg wsMessage
+		if err := conn.ReadJSON(&msg); err != nil {
+			if _, ok := err.(*websocket.CloseError); !ok {
+				c.close(wsBadRequest, "Invalid message received")
+			}
+			return
+		}
+
+		switch msg.Type {
+		case wsConnectionInit:
+			if acked {
+				c.close(wsTooManyInits, "Too many initialisation requests")
+				return
+			}
+
+			timer.Stop()
+			acked = true
+			c.send(wsMessage{Type: wsConnectionAck})
+
+		case wsPing:
+			c.send(wsMessage{Type: wsPong})
+
+		case wsPong:
+
+		case wsSubscribe:
+			if !acked {
+				c.close(wsUnauthorized, "Unauthorized")
+				return
+			}
+
+			var payload wsSubscribePayload
+			if msg.ID == "" || json.Unmarshal(msg.Payload, &payload) != nil {
+				c.close(wsBadRequest, "Invalid message received")
+				return
+			}
+
+			opCtx, ok := c.start(ctx, msg.ID)
+			if !ok {
+				c.close(wsSubscriberExists, fmt.Sprintf("Subscriber for %s already exists", msg.ID))
+				return
+			}
+
+			var params = graphql.Params{
+				RequestString:  payload.Query,
+				VariableValues: payload.Variables,
+				OperationName:  payload.OperationName,
+			}
+
+			go func(id string) {
+				defer c.wg.Done()
+				s.serveWSOperation(opCtx, &c, id, params)
+			}(msg.ID)
+
+		case wsComplete:
+			c.stop(msg.ID)
+
+		default:
+			c.close(wsBadRequest, "Invalid message received")
+			return
+		}
+	}
+}
+
+// serveWSOperation executes the operation with the given id, sending its results to the client.
+func (s *GraphServer) serveWSOperation(ctx context.Context, c *wsConn, id string, params graphql.Params) {
+	var first, failed = true, false
+	s.execute(ctx, params, func(result *graphql.Result) {
+		defer func() { first = false }()
+
+		// the client isn't interested anymore
+		if ctx.Err() != nil {
+			return
+		}
+
+		// an operation that couldn't be executed at all
+		if first && result.Data == nil && result.HasErrors() {
+			payload, _ := json.Marshal(result.Errors)
+			c.send(wsMessage{ID: id, Type: wsError, Payload: payload})
+			failed = true
+			return
+		}
+
+		payload, _ := json.Marshal(result)
+		c.send(wsMessage{ID: id, Type: wsNext, Payload: payload})
+	})
+
+	// an error ends the operation by itself, and the client already knows if it completed the operation
+	if c.stop(id) && !failed {
+		c.send(wsMessage{ID: id, Type: wsComplete})
+	}
+}
+
+// start registers the operation id, returning its context.
+// It reports false if there already is one with that id.
+func (c *wsConn) start(ctx context.Context, id string) (context.Context, bool) {
+	c.opsL.Lock()
+	defer c.opsL.Unlock()
+
+	if _, ok := c.ops[id]; ok {
+		return nil, false
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	c.ops[id] = cancel
+	c.wg.Add(1)
+
+	return ctx, true
+}
+
+// stop cancels the operation id, reporting whether it was still running.
+func (c *wsConn) stop(id string) bool {
+	c.opsL.Lock()
+	defer c.opsL.Unlock()
+
+	cancel, ok := c.ops[id]
+	if !ok {
+		return false
+	}
+
+	cancel()
+	delete(c.ops, id)
+
+	return true
+}
+
+func (c *wsConn) send(msg wsMessage) {
+	c.writeL.Lock()
+	defer c.writeL.Unlock()
+
+	c.conn.WriteJSON(msg)
+}
+
+func (c *wsConn) close(code int, reason string) {
+	c.writeL.Lock()
+	defer c.writeL.Unlock()
+
+	c.conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(code, reason))
+	c.conn.Close()
+}
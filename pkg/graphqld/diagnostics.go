@@ -0,0 +1,75 @@
+package graphqld
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/raphaelreyna/graphqld/internal/graph"
+)
+
+// Diagnostic is a problem found with a graph, located in the file it was found in.
+type Diagnostic struct {
+	File   string `json:"file,omitempty"`
+	Line   int    `json:"line,omitempty"`
+	Column int    `json:"column,omitempty"`
+
+	Message string `json:"message"`
+}
+
+// Location returns where the problem is, as file:line:column or as much of it as is known.
+func (d Diagnostic) Location() string {
+	if d.Line == 0 {
+		return d.File
+	}
+
+	return fmt.Sprintf("%s:%d:%d", d.File, d.Line, d.Column)
+}
+
+func (d Diagnostic) String() string {
+	if d.File == "" {
+		return d.Message
+	}
+
+	return d.Location() + ": " + d.Message
+}
+
+// Diagnostics are the problems that kept a graph from being built, ordered by file and position; they're returned as an error.
+type Diagnostics []Diagnostic
+
+func (ds Diagnostics) Error() string {
+	var b strings.Builder
+
+	if len(ds) == 1 {
+		b.WriteString("1 problem found in the graph:")
+	} else {
+		fmt.Fprintf(&b, "%d problems found in the graph:", len(ds))
+	}
+
+	for _, d := range ds {
+		b.WriteString("\n\t")
+		b.WriteString(d.String())
+	}
+
+	return b.String()
+}
+
+// diagnosticsFrom returns err as Diagnostics if it holds the problems found while building a graph, as is otherwise.
+func diagnosticsFrom(err error) error {
+	var gds graph.Diagnostics
+	if !errors.As(err, &gds) {
+		return err
+	}
+
+	var ds = make(Diagnostics, len(gds))
+	for idx, d := range gds.Sorted() {
+		ds[idx] = Diagnostic{
+			File:    d.File,
+			Line:    d.Line,
+			Column:  d.Column,
+			Message: d.Message,
+		}
+	}
+
+	return ds
+}
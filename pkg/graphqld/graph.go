@@ -0,0 +1,222 @@
+// Package graphqld builds GraphQL graphs from the executables and schema files of a document root and serves them,
+// for embedding graphqld in other Go programs.
+//
+//	g, err := graphqld.NewGraph(graphqld.GraphOptions{DocumentRoot: "./graph"})
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	defer g.Close()
+//
+//	http.Handle("/graphql", g)
+package graphqld
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"sort"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/graphql-go/graphql/language/parser"
+	"github.com/raphaelreyna/graphqld/internal/graph"
+	"github.com/raphaelreyna/graphqld/internal/graph/resolver"
+	"github.com/raphaelreyna/graphqld/internal/middleware"
+	"github.com/raphaelreyna/graphqld/internal/server"
+)
+
+var (
+	// ErrNotBuilt is returned when running a request against a hot reloaded graph that hasn't been built yet.
+	ErrNotBuilt = errors.New("the graph hasn't been built")
+
+	// ErrSubscription is returned when running a subscription in process, which needs a connection to deliver its events.
+	ErrSubscription = errors.New("subscriptions can't be run in process")
+)
+
+// Graph is a graph built from its document root. It serves GraphQL requests over HTTP at every path,
+// along with GraphiQL and the schema endpoints if they're enabled, and subscriptions over WebSockets and server-sent events.
+type Graph struct {
+	opts GraphOptions
+	s    *server.GraphServer
+}
+
+// NewGraph builds the graph configured by opts. If the graph can't be built the problems found are returned as Diagnostics,
+// unless it's hot reloaded: hot reloaded graphs are rebuilt whenever their document root changes, until they're closed.
+func NewGraph(opts GraphOptions) (*Graph, error) {
+	c, err := opts.conf()
+	if err != nil {
+		return nil, err
+	}
+
+	s, err := server.NewGraphServer(c)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.Start(); err != nil {
+		s.Stop()
+		return nil, diagnosticsFrom(err)
+	}
+
+	return &Graph{
+		opts: opts,
+		s:    s,
+	}, nil
+}
+
+// ServeHTTP serves GraphQL requests to the graph.
+func (g *Graph) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	g.s.ServeHTTP(w, r)
+}
+
+// Schema returns the schema of the current build of the graph; it returns false if the graph hasn't been built.
+func (g *Graph) Schema() (graphql.Schema, bool) {
+	return g.s.Schema()
+}
+
+// Reload rebuilds the graph from its document root. If it can't be built, the previous build is kept
+// and the problems found are returned as Diagnostics.
+func (g *Graph) Reload() error {
+	return diagnosticsFrom(g.s.UpdateSchema())
+}
+
+// Close stops watching the document root of the graph and any long-lived resolver processes started for it.
+func (g *Graph) Close() error {
+	g.s.Stop()
+	return nil
+}
+
+// Request is a GraphQL request along with what resolvers are given of the HTTP request it would have come in.
+type Request struct {
+	Query         string
+	OperationName string
+	Variables     map[string]interface{}
+
+	// Header holds the headers of the request, which resolvers are given as HTTP_ variables as well.
+	Header http.Header
+
+	// Env is added to the CGI environment derived from the request.
+	Env map[string]string
+
+	// Context, if not nil, is given to resolvers as JSON in place of the context configured for the graph.
+	Context interface{}
+}
+
+// Response is the result of a request along with the headers resolvers set on the response.
+type Response struct {
+	Result *graphql.Result
+	Header http.Header
+}
+
+// Do runs req against the graph in process, as if it had been posted to the graph.
+func (g *Graph) Do(ctx context.Context, req Request) (*Response, error) {
+	schema, done, built := g.s.Use()
+	defer done()
+	if !built {
+		return nil, ErrNotBuilt
+	}
+
+	if op := operation(req.Query, req.OperationName); op != nil && op.Operation == ast.OperationTypeSubscription {
+		return nil, ErrSubscription
+	}
+
+	var header = req.Header
+	if header == nil {
+		header = make(http.Header)
+	}
+
+	var host = g.opts.ServerName
+	if host == "" {
+		host = "localhost"
+	}
+
+	var env = middleware.Env(&http.Request{
+		Method: http.MethodPost,
+		URL:    &url.URL{Path: "/"},
+		Host:   host,
+		Header: header,
+	})
+
+	var keys = make([]string, 0, len(req.Env))
+	for k := range req.Env {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	// later variables take precedence
+	for _, k := range keys {
+		env = append(env, k+"="+req.Env[k])
+	}
+
+	var mreq = middleware.Request{
+		Env:            env,
+		Header:         header,
+		ResponseHeader: make(http.Header),
+	}
+
+	if req.Context != nil {
+		data, err := json.Marshal(req.Context)
+		if err != nil {
+			return nil, err
+		}
+		mreq.Context = data
+	}
+
+	ctx, release, err := g.s.NewContext(ctx, mreq)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	var result = graphql.Do(graphql.Params{
+		Schema:         schema,
+		RequestString:  req.Query,
+		VariableValues: req.Variables,
+		OperationName:  req.OperationName,
+		Context:        ctx,
+	})
+	result.Errors = resolver.FormatErrors(
+		append(result.Errors, middleware.GetErrors(ctx)...),
+	)
+
+	return &Response{
+		Result: result,
+		Header: mreq.ResponseHeader,
+	}, nil
+}
+
+// operation returns the operation of query named operationName, or its only one if operationName is empty;
+// it returns nil if query can't be parsed, leaving the error to be reported by its execution.
+func operation(query, operationName string) *ast.OperationDefinition {
+	doc, err := parser.Parse(parser.ParseParams{
+		Source: query,
+	})
+	if err != nil {
+		return nil
+	}
+
+	for _, def := range doc.Definitions {
+		op, ok := def.(*ast.OperationDefinition)
+		if !ok {
+			continue
+		}
+
+		if operationName == "" || (op.Name != nil && op.Name.Value == operationName) {
+			return op
+		}
+	}
+
+	return nil
+}
+
+// PrintSchema returns the SDL of schema, with its types, fields, arguments and enum values in alphabetical order.
+func PrintSchema(schema graphql.Schema) string {
+	return graph.PrintSchema(schema)
+}
+
+// Introspect returns the result of the standard introspection query against schema as JSON.
+func Introspect(schema graphql.Schema) ([]byte, error) {
+	return graph.Introspect(schema)
+}
@@ -0,0 +1,20 @@
+package graphqld
+
+import (
+	"github.com/raphaelreyna/graphqld/internal/limits"
+	"github.com/raphaelreyna/graphqld/internal/sandbox"
+)
+
+// RunHelper runs the helper the program was started as and exits, if it was started as one; it returns right away otherwise.
+// Resolver processes are sandboxed and limited by re-executing the running program as one of graphqld's helpers,
+// so programs using sandboxes or resource limits other than output have to call it first thing in main
+// (or in TestMain for tests); graphs using them fail to build otherwise.
+//
+//	func main() {
+//		graphqld.RunHelper()
+//		...
+//	}
+func RunHelper() {
+	sandbox.Main()
+	limits.Main()
+}
@@ -0,0 +1,358 @@
+package graphqld
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/raphaelreyna/graphqld/internal/config"
+	"github.com/raphaelreyna/graphqld/internal/sandbox"
+)
+
+// Limits are resource limits applied to resolver processes; zero limits aren't applied.
+// Resource limits other than Output are only available on Linux.
+type Limits struct {
+	// AddressSpace is the maximum size of a process's virtual memory in bytes.
+	AddressSpace int64
+
+	// CPU is the maximum amount of CPU time a process may use, rounded up to whole seconds.
+	CPU time.Duration
+
+	// OpenFiles is the maximum number of file descriptors a process may open.
+	OpenFiles int64
+
+	// Processes is the maximum number of processes the user running a process may have,
+	// counting every process of that user and not just those of the resolver.
+	Processes int64
+
+	// FileSize is the maximum size in bytes of any file a process writes.
+	FileSize int64
+
+	// Output is the maximum number of bytes read from each of a process's stdout and stderr.
+	Output int64
+}
+
+func (l Limits) conf() config.Limits {
+	return config.Limits{
+		AddressSpace: l.AddressSpace,
+		CPU:          l.CPU,
+		OpenFiles:    l.OpenFiles,
+		Processes:    l.Processes,
+		FileSize:     l.FileSize,
+		Output:       l.Output,
+	}
+}
+
+// SandboxProfile describes the Linux namespaces resolver processes are run in;
+// the document root of the graph is bound read-only into the sandbox.
+type SandboxProfile struct {
+	// Binds are other paths bound read-only into the sandbox, such as an executable living outside of the document root.
+	Binds []string
+
+	// Network lets processes share the hosts network; without it they only get their own loopback interface.
+	Network bool
+
+	// Seccomp is the allowlist of system calls processes may make; any other fails with EPERM.
+	// No filter is installed if it's empty.
+	Seccomp []string
+}
+
+func (p *SandboxProfile) conf(root string) *sandbox.Profile {
+	if p == nil {
+		return nil
+	}
+
+	return &sandbox.Profile{
+		Root:    root,
+		Binds:   p.Binds,
+		Network: p.Network,
+		Seccomp: p.Seccomp,
+	}
+}
+
+// FieldOptions configure the resolver of a single field.
+type FieldOptions struct {
+	// Timeout overrides the graphs resolver timeout for the field if set;
+	// a zero or negative timeout means the field has none.
+	Timeout *time.Duration
+
+	// Worker resolves the field with a pool of long-lived processes.
+	Worker *WorkerOptions
+
+	// FastCGI binds the field to a script run by a FastCGI application.
+	FastCGI *FastCGIOptions
+
+	// Topic binds a subscription field to the events published to a topic.
+	Topic *TopicOptions
+
+	// Limits override the graphs limits for the fields resolver.
+	Limits Limits
+}
+
+func (o FieldOptions) conf() config.FieldConf {
+	var fc = config.FieldConf{
+		Timeout: o.Timeout,
+		Limits:  o.Limits.conf(),
+	}
+
+	if w := o.Worker; w != nil {
+		fc.Worker = &config.WorkerConf{
+			PoolSize:    w.PoolSize,
+			MaxRequests: w.MaxRequests,
+		}
+	}
+
+	if f := o.FastCGI; f != nil {
+		fc.FastCGI = &config.FastCGIConf{
+			Address: f.Address,
+			Script:  f.Script,
+		}
+	}
+
+	if t := o.Topic; t != nil {
+		fc.Topic = &config.TopicConf{
+			Name:   t.Name,
+			Filter: t.Filter,
+		}
+	}
+
+	return fc
+}
+
+// WorkerOptions configure a pool of long-lived resolver processes.
+type WorkerOptions struct {
+	// PoolSize is the max number of worker processes.
+	//
+	// Default: the number of CPUs
+	PoolSize int
+
+	// MaxRequests is the number of requests a worker serves before being replaced; zero means no limit.
+	MaxRequests int
+}
+
+// FastCGIOptions bind a field to a script run by a FastCGI application.
+type FastCGIOptions struct {
+	// Address is either "unix:/path/to/socket" or "tcp:host:port".
+	Address string
+
+	// Script is sent as SCRIPT_FILENAME and is relative to the FastCGI application.
+	Script string
+}
+
+// TopicOptions bind a subscription field to the events published to a topic.
+type TopicOptions struct {
+	Name string
+
+	// Filter is an executable, relative to the document root, run for each event and subscriber;
+	// it decides whether to deliver the event and shapes it.
+	Filter string
+}
+
+// ContextOptions configure the context resolvers are given, either static or from an executable run for each request.
+type ContextOptions struct {
+	// ExecPath is the executable run for each request, whose output is the context.
+	ExecPath string
+
+	// TmpDir is where the context files handed to resolvers are created.
+	TmpDir string
+
+	// Context is given to every resolver as JSON if there's no executable.
+	Context interface{}
+}
+
+// CORSOptions configure the CORS headers of a graph.
+type CORSOptions struct {
+	AllowCredentials bool
+	AllowedHeaders   []string
+	AllowedOrigins   []string
+	IgnoreOptions    bool
+}
+
+// BasicAuth is the username and password a graph expects with HTTP basic authentication.
+type BasicAuth struct {
+	Username, Password string
+}
+
+// User is the user resolver processes are run as.
+type User struct {
+	Name    string
+	HomeDir string
+	Uid     uint32
+	Gid     uint32
+}
+
+// TLS holds the certificate and key a server is served with.
+type TLS struct {
+	CertFile, KeyFile string
+}
+
+// GraphOptions configure a graph; the zero value of each option is replaced by its default.
+type GraphOptions struct {
+	// DocumentRoot is the directory the graph is built from.
+	DocumentRoot string
+
+	// ServerName is the host the graph is served at when a server serves several graphs.
+	ServerName string
+
+	// ResolverDir is the working directory of resolvers.
+	//
+	// Default: "/"
+	ResolverDir string
+
+	// HotReload rebuilds the graph whenever its document root changes.
+	HotReload bool
+
+	// Graphiql serves GraphiQL at /graphiql.
+	Graphiql bool
+
+	// SchemaEndpoints serves the schema at /schema.graphql and /schema.json.
+	SchemaEndpoints bool
+
+	// Mock resolves fields with values made up from their types instead of running their resolvers.
+	Mock bool
+
+	// Record is a file every resolver process run for the graph is recorded to, and Replay a recording
+	// resolver processes are replayed from instead of being run.
+	Record, Replay string
+
+	// MaxBodySize is the max size of a request body.
+	//
+	// Default: 1MB
+	MaxBodySize int64
+
+	// ResolverTimeout is how long a resolver may run before it's terminated; zero means no timeout.
+	ResolverTimeout time.Duration
+
+	// MaxParallelism caps how many resolvers may run at once for the graph,
+	// MaxRequestParallelism how many may run at once for a single request.
+	//
+	// Default: 0 and 0 (no limit)
+	MaxParallelism        int
+	MaxRequestParallelism int
+
+	// ErrorCodes maps resolver exit statuses to the extensions code of the errors they report.
+	ErrorCodes map[int]string
+
+	// MaskErrors hides resolver errors from clients behind an error id unless they're marked as safe.
+	MaskErrors bool
+
+	// Limits are applied to every process started for the graph.
+	//
+	// Default: an output limit of 10MB
+	Limits Limits
+
+	// Sandbox is the profile processes started for the graph are sandboxed with, if any; its root is the document root.
+	Sandbox *SandboxProfile
+
+	// Fields holds field specific options keyed by "Object.field".
+	Fields map[string]FieldOptions
+
+	Context   *ContextOptions
+	CORS      *CORSOptions
+	BasicAuth *BasicAuth
+	User      *User
+}
+
+// conf returns the configuration of the graph used internally, with the defaults applied.
+func (o GraphOptions) conf() (config.GraphConf, error) {
+	if o.DocumentRoot == "" {
+		return config.GraphConf{}, errors.New("a graph needs a document root")
+	}
+
+	root, err := filepath.Abs(o.DocumentRoot)
+	if err != nil {
+		return config.GraphConf{}, fmt.Errorf("unable to compute absolute document root path: %w", err)
+	}
+
+	var c = config.NewGraphConf(root)
+
+	if o.ResolverDir != "" {
+		if c.ResolverDir, err = filepath.Abs(o.ResolverDir); err != nil {
+			return config.GraphConf{}, fmt.Errorf("unable to compute absolute resolver dir path: %w", err)
+		}
+	}
+
+	if o.MaxBodySize != 0 {
+		c.MaxBodyReadSize = o.MaxBodySize
+	}
+
+	if o.MaxParallelism != 0 {
+		c.MaxParallelism = o.MaxParallelism
+	}
+
+	if o.MaxRequestParallelism != 0 {
+		c.MaxRequestParallelism = o.MaxRequestParallelism
+	}
+
+	c.Limits = c.Limits.Merge(o.Limits.conf())
+	c.Sandbox = o.Sandbox.conf(root)
+
+	c.ServerName = o.ServerName
+	c.HotReload = o.HotReload
+	c.Graphiql = o.Graphiql
+	c.SchemaEndpoints = o.SchemaEndpoints
+	c.Mock = o.Mock
+	c.Record = o.Record
+	c.Replay = o.Replay
+	c.ResolverTimeout = o.ResolverTimeout
+	c.ErrorCodes = o.ErrorCodes
+	c.MaskErrors = o.MaskErrors
+
+	if 0 < len(o.Fields) {
+		c.Fields = make(map[string]config.FieldConf, len(o.Fields))
+		for name, fo := range o.Fields {
+			c.Fields[name] = fo.conf()
+		}
+	}
+
+	if ctx := o.Context; ctx != nil {
+		c.Context = &config.Context{
+			ExecPath: ctx.ExecPath,
+			TmpDir:   ctx.TmpDir,
+			Context:  ctx.Context,
+		}
+	}
+
+	if cors := o.CORS; cors != nil {
+		c.CORS = &config.CORSConfig{
+			AllowCredentials: cors.AllowCredentials,
+			AllowedHeaders:   cors.AllowedHeaders,
+			AllowedOrigins:   cors.AllowedOrigins,
+			IgnoreOptions:    cors.IgnoreOptions,
+		}
+	}
+
+	if ba := o.BasicAuth; ba != nil {
+		c.BasicAuth = &config.BasicAuth{
+			Username: ba.Username,
+			Password: ba.Password,
+		}
+	}
+
+	if u := o.User; u != nil {
+		c.User = &config.User{
+			Name:    u.Name,
+			HomeDir: u.HomeDir,
+			Uid:     u.Uid,
+			Gid:     u.Gid,
+		}
+	}
+
+	return c, nil
+}
+
+// Options configure a server.
+type Options struct {
+	// Addr is the address the server listens on.
+	//
+	// Default: ":http", or ":https" with TLS
+	Addr string
+
+	// TLS serves HTTPS with its certificate and key, if set.
+	TLS *TLS
+
+	// Graphs are the graphs served. A single graph is served whatever the host requests are made to;
+	// several graphs are each served at their ServerName.
+	Graphs []GraphOptions
+}
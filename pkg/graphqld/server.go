@@ -0,0 +1,71 @@
+package graphqld
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// Server serves one or more graphs over HTTP.
+type Server struct {
+	tls    *TLS
+	graphs []*Graph
+
+	http.Server
+}
+
+// NewServer builds the graphs configured by opts, returning the problems found with the first one that can't be built
+// as Diagnostics.
+func NewServer(opts Options) (*Server, error) {
+	var s = Server{
+		tls: opts.TLS,
+	}
+	s.Addr = opts.Addr
+
+	for _, gopts := range opts.Graphs {
+		g, err := NewGraph(gopts)
+		if err != nil {
+			s.closeGraphs()
+			return nil, err
+		}
+
+		s.graphs = append(s.graphs, g)
+	}
+
+	switch len(s.graphs) {
+	case 1:
+		s.Handler = s.graphs[0]
+	default:
+		var mux = mux.NewRouter()
+		for _, g := range s.graphs {
+			mux.Host(g.opts.ServerName).Handler(g)
+		}
+
+		s.Handler = mux
+	}
+
+	return &s, nil
+}
+
+// Start listens on the address of the server and serves its graphs until the server is closed.
+func (s *Server) Start() error {
+	switch tls := s.tls; tls {
+	case nil:
+		return s.Server.ListenAndServe()
+	default:
+		return s.Server.ListenAndServeTLS(tls.CertFile, tls.KeyFile)
+	}
+}
+
+// Close closes the graphs of the server, and the server along with its connections.
+func (s *Server) Close() error {
+	s.closeGraphs()
+
+	return s.Server.Close()
+}
+
+func (s *Server) closeGraphs() {
+	for _, g := range s.graphs {
+		g.Close()
+	}
+}
@@ -0,0 +1,336 @@
+package graphqldtest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/raphaelreyna/graphqld/internal/scan"
+	"gopkg.in/yaml.v2"
+)
+
+// Case is a request to a graph along with the response it's expected to get,
+// as kept in a .test.yaml file in the graphs document root.
+type Case struct {
+	// File is the path of the file the case was loaded from.
+	File string `yaml:"-"`
+
+	// Name defaults to the path of the file relative to the document root, without its suffix.
+	Name string `yaml:"name"`
+
+	Query         string                 `yaml:"query"`
+	OperationName string                 `yaml:"operationName"`
+	Variables     map[string]interface{} `yaml:"variables"`
+	Headers       map[string]string      `yaml:"headers"`
+	Env           map[string]string      `yaml:"env"`
+	Context       interface{}            `yaml:"context"`
+
+	Expect Expectation `yaml:"expect"`
+}
+
+// Expectation is what a case expects of its response.
+type Expectation struct {
+	// Data must match the data of the response exactly; it isn't checked if it's not given.
+	Data interface{} `yaml:"data"`
+
+	// HasData has Data checked even if it's nil, so that a case can expect null data;
+	// it's set for cases whose file gives data, null included.
+	HasData bool `yaml:"-"`
+
+	// Errors must match the errors of the response in order; a response with errors fails unless they're expected.
+	Errors []ExpectedError `yaml:"errors"`
+
+	// Headers must be set on the response with these values; other headers are ignored.
+	Headers map[string]string `yaml:"headers"`
+}
+
+// UnmarshalYAML tells data given as null apart from data that isn't given.
+func (e *Expectation) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type expectation Expectation
+	if err := unmarshal((*expectation)(e)); err != nil {
+		return err
+	}
+
+	var keys map[string]interface{}
+	if err := unmarshal(&keys); err != nil {
+		return err
+	}
+	_, e.HasData = keys["data"]
+
+	return nil
+}
+
+// ExpectedError is an error a case expects; its path and extensions are only checked if they're given,
+// and only the extensions given are.
+type ExpectedError struct {
+	Message    string                 `yaml:"message"`
+	Path       []interface{}          `yaml:"path"`
+	Extensions map[string]interface{} `yaml:"extensions"`
+}
+
+// LoadCases loads the cases kept in documentRoot, in the order of their files.
+func LoadCases(documentRoot string) ([]Case, error) {
+	var cases = make([]Case, 0)
+
+	err := filepath.WalkDir(documentRoot, func(path string, de fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if de.IsDir() || !strings.HasSuffix(de.Name(), scan.TestFileSuffix) {
+			return nil
+		}
+
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		var c Case
+		if err := yaml.UnmarshalStrict(data, &c); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		c.File = path
+
+		if c.Name == "" {
+			rel, err := filepath.Rel(documentRoot, path)
+			if err != nil {
+				rel = path
+			}
+			c.Name = strings.TrimSuffix(rel, scan.TestFileSuffix)
+		}
+
+		cases = append(cases, c)
+
+		return nil
+	})
+
+	return cases, err
+}
+
+// Result is the outcome of a case.
+type Result struct {
+	File     string        `json:"file"`
+	Name     string        `json:"name"`
+	Passed   bool          `json:"passed"`
+	Failures []string      `json:"failures,omitempty"`
+	Duration time.Duration `json:"duration"`
+}
+
+// Run runs c against the graph, checking its response against what c expects.
+func (r *Runner) Run(ctx context.Context, c Case) Result {
+	var (
+		result = Result{
+			File: c.File,
+			Name: c.Name,
+		}
+		start = time.Now()
+	)
+
+	var header = make(http.Header, len(c.Headers))
+	for k, v := range c.Headers {
+		header.Set(k, v)
+	}
+
+	var req = Request{
+		Query:         c.Query,
+		OperationName: c.OperationName,
+		Header:        header,
+		Env:           c.Env,
+		Context:       jsonValue(c.Context),
+	}
+
+	// variables are given to the graph as if they were decoded from a JSON request
+	if c.Variables != nil {
+		if v, ok := normalize(c.Variables).(map[string]interface{}); ok {
+			req.Variables = v
+		}
+	}
+
+	resp, err := r.Do(ctx, req)
+	result.Duration = time.Since(start)
+	if err != nil {
+		result.Failures = []string{err.Error()}
+		return result
+	}
+
+	result.Failures = c.Expect.check(resp)
+	result.Passed = len(result.Failures) == 0
+
+	return result
+}
+
+// check returns how resp doesn't meet e.
+func (e Expectation) check(resp *Response) []string {
+	var failures = make([]string, 0)
+
+	if e.HasData || e.Data != nil {
+		failures = append(failures, diff("data", normalize(e.Data), normalize(resp.Result.Data))...)
+	}
+
+	var errs, _ = normalize(resp.Result.Errors).([]interface{})
+	for idx, expected := range e.Errors {
+		if len(errs) <= idx {
+			failures = append(failures, fmt.Sprintf("expected error %q", expected.Message))
+			continue
+		}
+
+		var got, _ = errs[idx].(map[string]interface{})
+		if msg, _ := got["message"].(string); msg != expected.Message {
+			failures = append(failures, fmt.Sprintf("errors.%d.message: expected %q, got %q", idx, expected.Message, msg))
+		}
+
+		if expected.Path != nil {
+			failures = append(failures, diff(fmt.Sprintf("errors.%d.path", idx), normalize(expected.Path), got["path"])...)
+		}
+
+		var extensions, _ = got["extensions"].(map[string]interface{})
+		for k, v := range expected.Extensions {
+			failures = append(failures, diff(fmt.Sprintf("errors.%d.extensions.%s", idx, k), normalize(v), extensions[k])...)
+		}
+	}
+	for idx := len(e.Errors); idx < len(errs); idx++ {
+		var got, _ = errs[idx].(map[string]interface{})
+		failures = append(failures, fmt.Sprintf("unexpected error %q", got["message"]))
+	}
+
+	var names = make([]string, 0, len(e.Headers))
+	for name := range e.Headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		var (
+			expected = e.Headers[name]
+			got      = strings.Join(resp.Header.Values(name), ", ")
+		)
+
+		if got != expected {
+			failures = append(failures, fmt.Sprintf("header %s: expected %q, got %q", name, expected, got))
+		}
+	}
+
+	return failures
+}
+
+// diff returns where got differs from expected, both being decoded JSON values; path is where they are in the response.
+func diff(path string, expected, got interface{}) []string {
+	switch x := expected.(type) {
+	case map[string]interface{}:
+		y, ok := got.(map[string]interface{})
+		if !ok {
+			break
+		}
+
+		var keys = make([]string, 0, len(x)+len(y))
+		for k := range x {
+			keys = append(keys, k)
+		}
+		for k := range y {
+			if _, ok := x[k]; !ok {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+
+		var diffs []string
+		for _, k := range keys {
+			var (
+				ev, eok = x[k]
+				gv, gok = y[k]
+			)
+
+			switch {
+			case !gok:
+				diffs = append(diffs, fmt.Sprintf("%s.%s: missing, expected %s", path, k, describe(ev)))
+			case !eok:
+				diffs = append(diffs, fmt.Sprintf("%s.%s: unexpected %s", path, k, describe(gv)))
+			default:
+				diffs = append(diffs, diff(path+"."+k, ev, gv)...)
+			}
+		}
+
+		return diffs
+	case []interface{}:
+		y, ok := got.([]interface{})
+		if !ok {
+			break
+		}
+
+		if len(x) != len(y) {
+			return []string{fmt.Sprintf("%s: expected %d elements, got %d: %s", path, len(x), len(y), describe(y))}
+		}
+
+		var diffs []string
+		for idx := range x {
+			diffs = append(diffs, diff(fmt.Sprintf("%s.%d", path, idx), x[idx], y[idx])...)
+		}
+
+		return diffs
+	}
+
+	if !reflect.DeepEqual(expected, got) {
+		return []string{fmt.Sprintf("%s: expected %s, got %s", path, describe(expected), describe(got))}
+	}
+
+	return nil
+}
+
+func describe(v interface{}) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+
+	return string(data)
+}
+
+// normalize returns v as it would be decoded from JSON, so that values from YAML and from results compare equal.
+func normalize(v interface{}) interface{} {
+	data, err := json.Marshal(jsonValue(v))
+	if err != nil {
+		return v
+	}
+
+	var n interface{}
+	if err := json.Unmarshal(data, &n); err != nil {
+		return v
+	}
+
+	return n
+}
+
+// jsonValue converts the maps decoded from YAML, which can have keys of any type, into maps that can be encoded as JSON.
+func jsonValue(v interface{}) interface{} {
+	switch x := v.(type) {
+	case map[interface{}]interface{}:
+		var m = make(map[string]interface{}, len(x))
+		for k, v := range x {
+			m[fmt.Sprint(k)] = jsonValue(v)
+		}
+		return m
+	case map[string]interface{}:
+		var m = make(map[string]interface{}, len(x))
+		for k, v := range x {
+			m[k] = jsonValue(v)
+		}
+		return m
+	case []interface{}:
+		var l = make([]interface{}, len(x))
+		for idx, v := range x {
+			l[idx] = jsonValue(v)
+		}
+		return l
+	}
+
+	return v
+}
@@ -0,0 +1,253 @@
+package graphqldtest
+
+import (
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+)
+
+func writeFiles(t *testing.T, files map[string]string) string {
+	var root = t.TempDir()
+	for name, content := range files {
+		var path = filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := ioutil.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return root
+}
+
+func TestLoadCases(t *testing.T) {
+	var root = writeFiles(t, map[string]string{
+		"a.test.yaml": `
+query: '{ a }'
+expect:
+  data: {a: 1}
+`,
+		"Query/b.test.yaml": `
+name: null data
+query: '{ b }'
+variables: {x: 1}
+expect:
+  data: null
+  errors:
+    - message: boom
+`,
+		"c.test.yaml": `
+query: '{ c }'
+expect:
+  errors:
+    - message: boom
+`,
+		"schema.graphql": `type Query { a: Int }`,
+	})
+
+	cases, err := LoadCases(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var names = make([]string, len(cases))
+	for idx, c := range cases {
+		names[idx] = c.Name
+	}
+	if want := []string{"null data", "a", "c"}; !reflect.DeepEqual(names, want) {
+		t.Fatalf("expected cases %v, got %v", want, names)
+	}
+
+	if f := cases[0].File; f != filepath.Join(root, "Query", "b.test.yaml") {
+		t.Errorf("expected the file of the case to be kept, got %s", f)
+	}
+
+	var tests = []struct {
+		name    string
+		hasData bool
+		data    interface{}
+	}{
+		{name: "null data", hasData: true, data: nil},
+		{name: "a", hasData: true, data: map[interface{}]interface{}{"a": 1}},
+		{name: "c", hasData: false, data: nil},
+	}
+	for idx, tt := range tests {
+		var e = cases[idx].Expect
+		if e.HasData != tt.hasData {
+			t.Errorf("%s: expected HasData to be %t", tt.name, tt.hasData)
+		}
+
+		if !reflect.DeepEqual(e.Data, tt.data) {
+			t.Errorf("%s: expected data %#v, got %#v", tt.name, tt.data, e.Data)
+		}
+	}
+
+	if msg := cases[0].Expect.Errors[0].Message; msg != "boom" {
+		t.Errorf("expected the errors of the case to be loaded, got %q", msg)
+	}
+}
+
+func TestLoadCasesUnknownKey(t *testing.T) {
+	var root = writeFiles(t, map[string]string{
+		"a.test.yaml": `
+query: '{ a }'
+expect:
+  date: {a: 1}
+`,
+	})
+
+	if _, err := LoadCases(root); err == nil || !strings.Contains(err.Error(), "a.test.yaml") {
+		t.Fatalf("expected an error naming the file with an unknown key, got %v", err)
+	}
+}
+
+func TestExpectationCheck(t *testing.T) {
+	var (
+		header = http.Header{"X-User": {"ann"}}
+		resp   = func(data interface{}, errs ...gqlerrors.FormattedError) *Response {
+			return &Response{
+				Result: &graphql.Result{Data: data, Errors: errs},
+				Header: header,
+			}
+		}
+	)
+
+	var tests = []struct {
+		name   string
+		expect Expectation
+		resp   *Response
+		want   []string
+	}{
+		{
+			name:   "data",
+			expect: Expectation{HasData: true, Data: map[interface{}]interface{}{"a": 1}},
+			resp:   resp(map[string]interface{}{"a": 1}),
+		},
+		{
+			name:   "data not given",
+			expect: Expectation{},
+			resp:   resp(map[string]interface{}{"a": 1}),
+		},
+		{
+			name:   "null data",
+			expect: Expectation{HasData: true},
+			resp:   resp(nil),
+		},
+		{
+			name:   "null data expected",
+			expect: Expectation{HasData: true},
+			resp:   resp(map[string]interface{}{"a": 1}),
+			want:   []string{`data: expected null, got {"a":1}`},
+		},
+		{
+			name: "errors",
+			expect: Expectation{Errors: []ExpectedError{
+				{Message: "boom", Path: []interface{}{"fail", 0}, Extensions: map[string]interface{}{"code": "C"}},
+			}},
+			resp: resp(nil, gqlerrors.FormattedError{
+				Message:    "boom",
+				Path:       []interface{}{"fail", 0},
+				Extensions: map[string]interface{}{"code": "C", "id": "x"},
+			}),
+		},
+		{
+			name:   "error mismatch",
+			expect: Expectation{Errors: []ExpectedError{{Message: "boom", Path: []interface{}{"a"}}, {Message: "bang"}}},
+			resp:   resp(nil, gqlerrors.FormattedError{Message: "bam", Path: []interface{}{"b"}}),
+			want: []string{
+				`errors.0.message: expected "boom", got "bam"`,
+				`errors.0.path.0: expected "a", got "b"`,
+				`expected error "bang"`,
+			},
+		},
+		{
+			name:   "unexpected error",
+			expect: Expectation{},
+			resp:   resp(nil, gqlerrors.FormattedError{Message: "boom"}),
+			want:   []string{`unexpected error "boom"`},
+		},
+		{
+			name:   "headers",
+			expect: Expectation{Headers: map[string]string{"X-User": "ann", "X-Team": "core"}},
+			resp:   resp(nil),
+			want:   []string{`header X-Team: expected "core", got ""`},
+		},
+	}
+
+	for _, tt := range tests {
+		var got = tt.expect.check(tt.resp)
+		if len(got) == 0 && len(tt.want) == 0 {
+			continue
+		}
+
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: expected %q, got %q", tt.name, tt.want, got)
+		}
+	}
+}
+
+func TestDiff(t *testing.T) {
+	var tests = []struct {
+		name     string
+		expected interface{}
+		got      interface{}
+		want     []string
+	}{
+		{
+			name:     "equal",
+			expected: map[string]interface{}{"a": []interface{}{1.0, "x"}},
+			got:      map[string]interface{}{"a": []interface{}{1.0, "x"}},
+		},
+		{
+			name:     "nested value",
+			expected: map[string]interface{}{"a": map[string]interface{}{"b": 1.0}},
+			got:      map[string]interface{}{"a": map[string]interface{}{"b": 2.0}},
+			want:     []string{"data.a.b: expected 1, got 2"},
+		},
+		{
+			name:     "missing and unexpected keys",
+			expected: map[string]interface{}{"a": 1.0, "b": 2.0},
+			got:      map[string]interface{}{"b": 2.0, "c": 3.0},
+			want:     []string{"data.a: missing, expected 1", "data.c: unexpected 3"},
+		},
+		{
+			name:     "list length",
+			expected: []interface{}{1.0, 2.0},
+			got:      []interface{}{1.0},
+			want:     []string{"data: expected 2 elements, got 1: [1]"},
+		},
+		{
+			name:     "list element",
+			expected: []interface{}{1.0, 2.0},
+			got:      []interface{}{1.0, 3.0},
+			want:     []string{"data.1: expected 2, got 3"},
+		},
+		{
+			name:     "type mismatch",
+			expected: map[string]interface{}{"a": 1.0},
+			got:      []interface{}{1.0},
+			want:     []string{`data: expected {"a":1}, got [1]`},
+		},
+		{
+			name:     "null",
+			expected: nil,
+			got:      map[string]interface{}{},
+			want:     []string{"data: expected null, got {}"},
+		},
+	}
+
+	for _, tt := range tests {
+		if got := diff("data", tt.expected, tt.got); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: expected %q, got %q", tt.name, tt.want, got)
+		}
+	}
+}
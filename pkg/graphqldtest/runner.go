@@ -0,0 +1,72 @@
+// Package graphqldtest runs queries against a graph in process, without serving it,
+// and checks graphs against the test cases kept in their document root.
+//
+// Tests of graphs using sandboxes or resource limits have to run graphqld's helpers from TestMain:
+//
+//	func TestMain(m *testing.M) {
+//		graphqld.RunHelper()
+//		os.Exit(m.Run())
+//	}
+package graphqldtest
+
+import (
+	"context"
+	"path/filepath"
+
+	"github.com/graphql-go/graphql"
+	"github.com/raphaelreyna/graphqld/pkg/graphqld"
+)
+
+// ErrSubscription is returned when running a subscription, which needs a server to deliver its events.
+var ErrSubscription = graphqld.ErrSubscription
+
+type (
+	// Request is a query along with what resolvers are given of the request it came in.
+	Request = graphqld.Request
+
+	// Response is the result of a request along with the headers resolvers set on the response.
+	Response = graphqld.Response
+)
+
+// Runner runs queries against a built graph.
+type Runner struct {
+	graph *graphqld.Graph
+}
+
+// Load builds the graph at documentRoot with the default options.
+func Load(documentRoot string) (*Runner, error) {
+	path, err := filepath.Abs(documentRoot)
+	if err != nil {
+		return nil, err
+	}
+
+	return New(graphqld.GraphOptions{DocumentRoot: path})
+}
+
+// New builds the graph configured by opts; it isn't hot reloaded.
+func New(opts graphqld.GraphOptions) (*Runner, error) {
+	opts.HotReload = false
+
+	g, err := graphqld.NewGraph(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Runner{graph: g}, nil
+}
+
+// Close stops any long-lived worker processes started for the graph.
+func (r *Runner) Close() error {
+	return r.graph.Close()
+}
+
+// Schema returns the schema of the graph.
+func (r *Runner) Schema() graphql.Schema {
+	schema, _ := r.graph.Schema()
+	return schema
+}
+
+// Do runs req against the graph.
+func (r *Runner) Do(ctx context.Context, req Request) (*Response, error) {
+	return r.graph.Do(ctx, req)
+}